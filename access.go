@@ -0,0 +1,434 @@
+package shamir
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Gate is a node of an access structure, see SplitAccess. A gate without
+// children is a leaf and stands for a participant. A gate with children is
+// satisfied if at least K of its children are.
+type Gate struct {
+	// Name is used in errors, and is required to be at most 255 bytes long.
+	Name string
+
+	// K is the number of children that must be satisfied. It is ignored for
+	// leaves.
+	K int
+
+	Children []*Gate
+}
+
+// Participant returns a leaf for the named participant.
+func Participant(name string) *Gate {
+	return &Gate{Name: name}
+}
+
+// And returns a gate that needs all of its children.
+func And(children ...*Gate) *Gate {
+	return &Gate{K: len(children), Children: children}
+}
+
+// Or returns a gate that needs one of its children.
+func Or(children ...*Gate) *Gate {
+	return &Gate{K: 1, Children: children}
+}
+
+// AtLeast returns a gate that needs k of its children.
+func AtLeast(k int, children ...*Gate) *Gate {
+	return &Gate{K: k, Children: children}
+}
+
+// GateStatus describes a gate that is not satisfied by the bundles passed to
+// CombineAccess.
+type GateStatus struct {
+	Path []int  // indices of the children on the way from the root to the gate
+	Name string // name of the gate, if any
+	Have int    // number of satisfied children
+	Need int    // number of children that must be satisfied
+	N    int    // number of children
+
+	// Err is set if the gate has enough satisfied children, but their shares
+	// could not be combined, e.g. because a bundle is corrupt.
+	Err error
+}
+
+// AccessError is returned by CombineAccess if the bundles do not satisfy the
+// access structure. Missing lists every gate that is not satisfied, including
+// gates without a bundle in any of their leaves, in depth-first order,
+// starting with the gates closest to the leaves. It matches
+// ErrInsufficientOrInvalidShares, and the errors of gates that could not be
+// combined.
+type AccessError struct {
+	Missing []GateStatus
+}
+
+func (e *AccessError) Error() string {
+	var b strings.Builder
+	b.WriteString(ErrInsufficientOrInvalidShares.Error())
+	for i, g := range e.Missing {
+		if i == 0 {
+			b.WriteString(": ")
+		} else {
+			b.WriteString("; ")
+		}
+
+		if g.Err != nil {
+			fmt.Fprintf(&b, "gate %s: %v", g.String(), g.Err)
+		} else {
+			fmt.Fprintf(&b, "gate %s has %d of %d satisfied children, needs %d", g.String(), g.Have, g.N, g.Need)
+		}
+	}
+
+	return b.String()
+}
+
+func (e *AccessError) Is(target error) bool {
+	return target == ErrInsufficientOrInvalidShares
+}
+
+func (e *AccessError) Unwrap() []error {
+	var errs []error
+	for _, g := range e.Missing {
+		if g.Err != nil {
+			errs = append(errs, g.Err)
+		}
+	}
+
+	return errs
+}
+
+// String returns the name of the gate, or its path if it has no name.
+func (g GateStatus) String() string {
+	if g.Name != "" {
+		return strconv.Quote(g.Name)
+	}
+
+	path := "root"
+	for _, i := range g.Path {
+		path += "/" + strconv.Itoa(i)
+	}
+
+	return path
+}
+
+// The binary encoding of a bundle of an access structure is
+//
+//	magic [4]byte | version uint8 | count uint16 | nodes [count]node |
+//	depth uint8 | path [depth]uint16 | share
+//
+// where nodes describe the whole access structure in depth-first order, path
+// holds the indices of the children on the way from the root to the leaf of
+// the bundle, and share is the encoded share of the leaf from the dealing of
+// its parent gate. A node is
+//
+//	k uint16 | n uint16 | length uint8 | name [length]byte
+//
+// where n is the number of children, and k and n are 0 for a leaf. All
+// integers are big-endian.
+var accessMagic = [4]byte{'S', 'H', 'M', 'A'}
+
+const accessVersion = 1
+
+// SplitAccess splits a secret according to an access structure, e.g.
+//
+//	policy := Or(
+//		And(
+//			&Gate{Name: "legal", K: 2, Children: legal},
+//			&Gate{Name: "engineering", K: 3, Children: engineering},
+//		),
+//		Participant("escrow agent"),
+//	)
+//
+// where legal and engineering are leaves created with Participant. The secret
+// is split with Split among the children of the root, every child that is a
+// gate splits its share among its own children in turn, and so on. SplitAccess
+// returns one bundle per leaf, in depth-first order, which records the access
+// structure and the path from the root to the leaf. A participant that appears
+// in several leaves gets several bundles. The root must not be a leaf, the
+// tree has at most 65535 nodes, and it is at most 255 levels deep.
+func (d *Dealer) SplitAccess(policy *Gate, secret []byte) ([][]byte, error) {
+	d.init()
+
+	if policy == nil || len(policy.Children) == 0 {
+		return nil, fmt.Errorf("%w: root of the access structure must be a gate", ErrInvalidParams)
+	}
+
+	b := append([]byte(nil), accessMagic[:]...)
+	b = append(b, accessVersion, 0, 0)
+	count := 0
+	b, err := appendGate(b, policy, nil, &count)
+	if err != nil {
+		return nil, err
+	}
+	binary.BigEndian.PutUint16(b[5:], uint16(count))
+
+	var bundles [][]byte
+	err = d.splitGate(policy, secret, b, nil, &bundles)
+	if err != nil {
+		return nil, err
+	}
+
+	return bundles, nil
+}
+
+// CombineAccess recovers a secret from bundles created by SplitAccess. It
+// recovers the share of every gate that is satisfied, from the leaves up to
+// the root. A gate whose shares can not be combined, e.g. because a bundle is
+// corrupt, counts as not satisfied, so other branches can still satisfy its
+// parent. If the root is not satisfied, CombineAccess returns an *AccessError
+// that lists the gates that are not satisfied.
+func (d *Dealer) CombineAccess(bundles [][]byte) ([]byte, error) {
+	d.init()
+
+	if len(bundles) == 0 {
+		return nil, ErrNoShares
+	}
+
+	var root *accessNode
+	var tree []byte
+	for i, b := range bundles {
+		t, path, share, err := splitAccessBundle(b)
+		if err != nil {
+			return nil, &ShareError{Index: i, Err: err}
+		}
+
+		if i == 0 {
+			root, err = parseAccessTree(t)
+			if err != nil {
+				return nil, &ShareError{Index: i, Err: err}
+			}
+			tree = t
+		}
+		if !bytes.Equal(t, tree) {
+			return nil, &ShareError{Index: i, Err: fmt.Errorf("%w: bundle is from a different access structure", ErrParamsMismatch)}
+		}
+
+		leaf := root
+		for _, c := range path {
+			if c >= len(leaf.children) {
+				return nil, &ShareError{Index: i, Err: fmt.Errorf("%w: path does not match the access structure", ErrMalformedShare)}
+			}
+			leaf = leaf.children[c]
+		}
+		if leaf.k != 0 {
+			return nil, &ShareError{Index: i, Err: fmt.Errorf("%w: path does not end at a leaf", ErrMalformedShare)}
+		}
+		if leaf.share != nil {
+			return nil, &ShareError{Index: i, Err: fmt.Errorf("%w: same leaf as bundle %d", ErrDuplicateShare, leaf.bundle)}
+		}
+		leaf.share, leaf.bundle = share, i
+	}
+
+	var missing []GateStatus
+	secret, ok := d.combineGate(root, nil, &missing)
+	if !ok {
+		return nil, &AccessError{Missing: missing}
+	}
+
+	return secret, nil
+}
+
+// SplitAccess splits a secret according to an access structure using the
+// default dealer.
+func SplitAccess(policy *Gate, secret []byte) ([][]byte, error) {
+	return Default.SplitAccess(policy, secret)
+}
+
+// CombineAccess combines bundles of an access structure using the default
+// dealer.
+func CombineAccess(bundles [][]byte) ([]byte, error) {
+	return Default.CombineAccess(bundles)
+}
+
+// node of the access structure decoded from a bundle
+type accessNode struct {
+	name     string
+	k        int
+	children []*accessNode
+	share    []byte // of a leaf, if there is a bundle for it
+	bundle   int    // index of the bundle of a leaf
+}
+
+// append the nodes of the access structure rooted at g, the gate at path
+// indices, in depth-first order and count them
+func appendGate(b []byte, g *Gate, indices []int, count *int) ([]byte, error) {
+	if g == nil {
+		return nil, fmt.Errorf("gate %v: %w: nil gate", GateStatus{Path: indices}, ErrInvalidParams)
+	}
+	if len(g.Name) > 255 {
+		return nil, fmt.Errorf("gate %v: %w: name too long", GateStatus{Path: indices}, ErrInvalidParams)
+	}
+	if len(indices) == 255 {
+		return nil, fmt.Errorf("gate %v: %w: access structure too deep", GateStatus{Path: indices, Name: g.Name}, ErrInvalidParams)
+	}
+	if len(g.Children) > math.MaxUint16 {
+		return nil, fmt.Errorf("gate %v: %w", GateStatus{Path: indices, Name: g.Name}, ErrShareCount)
+	}
+	*count++
+	if *count > math.MaxUint16 {
+		return nil, fmt.Errorf("%w: access structure has more than %d nodes", ErrInvalidParams, math.MaxUint16)
+	}
+
+	k := g.K
+	if len(g.Children) == 0 {
+		k = 0
+	} else if k < 1 || k > len(g.Children) {
+		// checked by Split as well, but k must fit the encoding
+		return nil, fmt.Errorf("gate %v: %w", GateStatus{Path: indices, Name: g.Name}, checkParams(k, len(g.Children)))
+	}
+
+	b = binary.BigEndian.AppendUint16(b, uint16(k))
+	b = binary.BigEndian.AppendUint16(b, uint16(len(g.Children)))
+	b = append(b, uint8(len(g.Name)))
+	b = append(b, g.Name...)
+
+	for i, child := range g.Children {
+		var err error
+		b, err = appendGate(b, child, append(indices[:len(indices):len(indices)], i), count)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+// split secret among the children of g, or append the bundle of the leaf g
+// with its share secret, where tree is the start of every bundle and indices
+// is the path to g
+func (d *Dealer) splitGate(g *Gate, secret, tree []byte, indices []int, bundles *[][]byte) error {
+	if len(g.Children) == 0 {
+		b := append(tree[:len(tree):len(tree)], uint8(len(indices)))
+		for _, i := range indices {
+			b = binary.BigEndian.AppendUint16(b, uint16(i))
+		}
+		*bundles = append(*bundles, append(b, secret...))
+		return nil
+	}
+
+	shares, err := d.Split(g.K, len(g.Children), secret)
+	if err != nil {
+		return fmt.Errorf("gate %v: %w", GateStatus{Path: indices, Name: g.Name}, err)
+	}
+
+	for i, child := range g.Children {
+		err = d.splitGate(child, shares[i], tree, append(indices[:len(indices):len(indices)], i), bundles)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recover the share of the gate n, or the secret for the root, and append the
+// status of every gate that is not satisfied to missing
+func (d *Dealer) combineGate(n *accessNode, path []int, missing *[]GateStatus) ([]byte, bool) {
+	if n.k == 0 {
+		return n.share, n.share != nil
+	}
+
+	var shares [][]byte
+	for i, child := range n.children {
+		share, ok := d.combineGate(child, append(path[:len(path):len(path)], i), missing)
+		if ok {
+			shares = append(shares, share)
+		}
+	}
+
+	status := GateStatus{Path: path, Name: n.name, Have: len(shares), Need: n.k, N: len(n.children)}
+	if len(shares) < n.k {
+		*missing = append(*missing, status)
+		return nil, false
+	}
+
+	secret, err := d.Combine(shares)
+	if err != nil {
+		status.Err = err
+		*missing = append(*missing, status)
+		return nil, false
+	}
+
+	return secret, true
+}
+
+// split a bundle into the encoded access structure, the path to its leaf and
+// its share
+func splitAccessBundle(b []byte) (tree []byte, path []int, share []byte, err error) {
+	if len(b) < 7 || [4]byte(b) != accessMagic {
+		return nil, nil, nil, fmt.Errorf("%w: not a bundle of an access structure", ErrMalformedShare)
+	}
+	if b[4] != accessVersion {
+		return nil, nil, nil, fmt.Errorf("%w: unsupported version", ErrMalformedShare)
+	}
+
+	count := int(binary.BigEndian.Uint16(b[5:]))
+	n := 7
+	for range count {
+		if len(b) < n+5 || len(b) < n+5+int(b[n+4]) {
+			return nil, nil, nil, fmt.Errorf("%w: bundle too short", ErrMalformedShare)
+		}
+		n += 5 + int(b[n+4])
+	}
+	tree, b = b[:n], b[n:]
+
+	if len(b) < 1 || len(b) < 1+2*int(b[0]) {
+		return nil, nil, nil, fmt.Errorf("%w: bundle too short", ErrMalformedShare)
+	}
+	path = make([]int, b[0])
+	for i := range path {
+		path[i] = int(binary.BigEndian.Uint16(b[1+2*i:]))
+	}
+
+	return tree, path, b[1+2*len(path):], nil
+}
+
+// decode the access structure encoded by appendGate, which has been checked
+// by splitAccessBundle to consist of complete nodes
+func parseAccessTree(tree []byte) (*accessNode, error) {
+	count := int(binary.BigEndian.Uint16(tree[5:]))
+	b := tree[7:]
+
+	var parse func(depth int) (*accessNode, error)
+	parse = func(depth int) (*accessNode, error) {
+		if count == 0 {
+			return nil, fmt.Errorf("%w: access structure too short", ErrMalformedShare)
+		}
+		count--
+
+		k := int(binary.BigEndian.Uint16(b))
+		n := int(binary.BigEndian.Uint16(b[2:]))
+		node := &accessNode{name: string(b[5 : 5+int(b[4])]), k: k}
+		b = b[5+int(b[4]):]
+		if (k == 0) != (n == 0) || k > n || depth == 255 {
+			return nil, fmt.Errorf("%w: invalid gate", ErrMalformedShare)
+		}
+
+		// every child is a node of the encoding, so appending allocates no
+		// more than the encoding accounts for
+		for range n {
+			child, err := parse(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			node.children = append(node.children, child)
+		}
+
+		return node, nil
+	}
+
+	root, err := parse(0)
+	if err != nil {
+		return nil, err
+	}
+	if count != 0 || root.k == 0 {
+		return nil, fmt.Errorf("%w: invalid access structure", ErrMalformedShare)
+	}
+
+	return root, nil
+}
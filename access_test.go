@@ -0,0 +1,172 @@
+package shamir
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSplitAccess(t *testing.T) {
+	secret := []byte("recovery key")
+
+	// (2 of legal) AND (3 of engineering) OR (the escrow agent)
+	policy := Or(
+		And(
+			&Gate{Name: "legal", K: 2, Children: []*Gate{Participant("alice"), Participant("bob"), Participant("carol")}},
+			&Gate{Name: "engineering", K: 3, Children: []*Gate{Participant("dave"), Participant("erin"), Participant("frank"), Participant("grace")}},
+		),
+		Participant("escrow agent"),
+	)
+
+	bundles, err := SplitAccess(policy, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bundles) != 8 {
+		t.Fatalf("expected 8 bundles, got %d", len(bundles))
+	}
+	legal, engineering, escrow := bundles[:3], bundles[3:7], bundles[7]
+
+	other, err := SplitAccess(Or(Participant("alice"), Participant("bob")), secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		bundles [][]byte
+		wantErr error
+	}{
+		{name: "escrow agent", bundles: [][]byte{escrow}},
+		{name: "legal and engineering", bundles: [][]byte{engineering[3], legal[0], engineering[1], legal[2], engineering[0]}},
+		{name: "everyone", bundles: bundles},
+		{name: "legal only", bundles: legal, wantErr: ErrInsufficientOrInvalidShares},
+		{name: "too few engineers", bundles: [][]byte{legal[0], legal[1], engineering[0], engineering[1]}, wantErr: ErrInsufficientOrInvalidShares},
+		{name: "same bundle twice", bundles: [][]byte{legal[0], legal[0], engineering[0], engineering[1], engineering[2]}, wantErr: ErrDuplicateShare},
+		{name: "truncated bundle", bundles: [][]byte{escrow[:len(escrow)-1]}, wantErr: ErrMalformedShare},
+		{name: "plain share", bundles: [][]byte{escrow[6:]}, wantErr: ErrMalformedShare},
+		{name: "other access structure", bundles: [][]byte{escrow, other[0]}, wantErr: ErrParamsMismatch},
+		{name: "nil bundles", wantErr: ErrNoShares},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CombineAccess(tt.bundles)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, secret) {
+				t.Fatalf("expected %q, got %q", secret, got)
+			}
+		})
+	}
+}
+
+func TestCombineAccess_missing(t *testing.T) {
+	policy := Or(
+		And(
+			&Gate{Name: "legal", K: 2, Children: []*Gate{Participant("alice"), Participant("bob"), Participant("carol")}},
+			AtLeast(3, Participant("dave"), Participant("erin"), Participant("frank"), Participant("grace")),
+		),
+		Participant("escrow agent"),
+	)
+
+	bundles, err := SplitAccess(policy, []byte("recovery key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = CombineAccess([][]byte{bundles[0], bundles[3], bundles[4]})
+	var accessErr *AccessError
+	if !errors.As(err, &accessErr) {
+		t.Fatalf("expected *AccessError, got %v", err)
+	}
+
+	want := []GateStatus{
+		{Path: []int{0, 0}, Name: "legal", Have: 1, Need: 2, N: 3},
+		{Path: []int{0, 1}, Have: 2, Need: 3, N: 4},
+		{Path: []int{0}, Have: 0, Need: 2, N: 2},
+		{Have: 0, Need: 1, N: 2},
+	}
+	if !reflect.DeepEqual(accessErr.Missing, want) {
+		t.Fatalf("expected %v, got %v", want, accessErr.Missing)
+	}
+
+	// gates without any bundle are listed too
+	_, err = CombineAccess(bundles[:2])
+	if !errors.As(err, &accessErr) {
+		t.Fatalf("expected *AccessError, got %v", err)
+	}
+
+	want = []GateStatus{
+		{Path: []int{0, 1}, Have: 0, Need: 3, N: 4},
+		{Path: []int{0}, Have: 1, Need: 2, N: 2},
+		{Have: 0, Need: 1, N: 2},
+	}
+	if !reflect.DeepEqual(accessErr.Missing, want) {
+		t.Fatalf("expected %v, got %v", want, accessErr.Missing)
+	}
+}
+
+func TestCombineAccess_corrupt(t *testing.T) {
+	policy := Or(
+		&Gate{Name: "legal", K: 2, Children: []*Gate{Participant("alice"), Participant("bob"), Participant("carol")}},
+		Participant("escrow agent"),
+	)
+
+	bundles, err := SplitAccess(policy, []byte("recovery key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := SplitAccess(policy, []byte("recovery key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// bob's bundle is from another dealing, so legal can not be combined,
+	// but the escrow agent still satisfies the root
+	got, err := CombineAccess([][]byte{bundles[0], other[1], bundles[3]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, []byte("recovery key")) {
+		t.Fatalf("expected %q, got %q", "recovery key", got)
+	}
+
+	_, err = CombineAccess([][]byte{bundles[0], other[1]})
+	var accessErr *AccessError
+	if !errors.As(err, &accessErr) || !errors.Is(err, ErrDifferentDealing) {
+		t.Fatalf("expected *AccessError matching %v, got %v", ErrDifferentDealing, err)
+	}
+	if len(accessErr.Missing) != 2 || accessErr.Missing[0].Name != "legal" || accessErr.Missing[0].Err == nil {
+		t.Fatalf("expected legal to fail to combine, got %v", accessErr.Missing)
+	}
+}
+
+func TestSplitAccess_invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *Gate
+		want   error
+	}{
+		{name: "threshold too large", policy: AtLeast(3, Participant("alice"), Participant("bob")), want: ErrThresholdTooLarge},
+		{name: "nested threshold too small", policy: Or(Participant("alice"), AtLeast(0, Participant("bob"))), want: ErrThresholdTooSmall},
+		{name: "leaf as root", policy: Participant("alice"), want: ErrInvalidParams},
+		{name: "nil root", want: ErrInvalidParams},
+		{name: "nil child", policy: Or(Participant("alice"), nil), want: ErrInvalidParams},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := SplitAccess(tt.policy, []byte("secret"))
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package shamir
+
+import "fmt"
+
+// Accumulator collects the shares of a secret one at a time, e.g. as holders
+// show up during an unseal ceremony. Every share is validated the moment it is
+// added, so a bad share is rejected before it can spoil the reconstruction.
+type Accumulator struct {
+	d         *Dealer
+	threshold int
+	shares    []Share
+	xvals     map[uint16]int
+}
+
+// NewAccumulator returns an Accumulator for the shares of a secret that was
+// split with the given threshold. A threshold of 0 means that the threshold is
+// taken from the first share that records it. If no share records it, the
+// Accumulator can not tell when it is ready.
+func (d *Dealer) NewAccumulator(threshold int) *Accumulator {
+	d.init()
+
+	return &Accumulator{
+		d:         d,
+		threshold: threshold,
+		xvals:     make(map[uint16]int),
+	}
+}
+
+// NewAccumulator returns an Accumulator using the default dealer.
+func NewAccumulator(threshold int) *Accumulator {
+	return Default.NewAccumulator(threshold)
+}
+
+// Add adds a share. It returns an error and leaves the Accumulator unchanged
+// if the share is malformed, is from a different dealing or of a different
+// length than the shares added before, or has the same x coordinate as a share
+// added before.
+func (a *Accumulator) Add(share []byte) error {
+	s, err := a.d.ParseShare(share)
+	if err != nil {
+		return err
+	}
+
+	if s.threshold != 0 && a.threshold != 0 && s.threshold != a.threshold {
+		return fmt.Errorf("%w: share records %d, expected %d", ErrThresholdMismatch, s.threshold, a.threshold)
+	}
+	if len(a.shares) > 0 {
+		if s.setID != a.shares[0].setID {
+			return ErrDifferentDealing
+		}
+		if s.header != a.shares[0].header {
+			return ErrParamsMismatch
+		}
+		if s.Len() != a.shares[0].Len() || len(s.words) != len(a.shares[0].words) {
+			return ErrLengthMismatch
+		}
+		if s.proof != nil && s.proof.root != a.shares[0].proof.root {
+			return ErrManifestMismatch
+		}
+	}
+	if i, ok := a.xvals[s.X()]; ok {
+		return &DuplicateShareError{I: i, J: len(a.shares), X: s.X()}
+	}
+
+	if a.threshold == 0 {
+		a.threshold = s.threshold
+	}
+	a.xvals[s.X()] = len(a.shares)
+	a.shares = append(a.shares, s)
+
+	return nil
+}
+
+// Len returns the number of shares added so far.
+func (a *Accumulator) Len() int {
+	return len(a.shares)
+}
+
+// Needed returns the number of shares still needed to reach the threshold, or
+// -1 if the threshold is unknown.
+func (a *Accumulator) Needed() int {
+	if a.threshold == 0 {
+		return -1
+	}
+
+	return max(a.threshold-len(a.shares), 0)
+}
+
+// Ready reports whether enough shares have been added to recover the secret.
+func (a *Accumulator) Ready() bool {
+	return a.Needed() == 0
+}
+
+// Combine recovers the secret from the shares added so far. If the threshold
+// is known, only the first threshold shares are used.
+func (a *Accumulator) Combine() ([]byte, error) {
+	shares := a.shares
+	if a.threshold > 0 && len(shares) > a.threshold {
+		shares = shares[:a.threshold]
+	}
+
+	return a.d.CombineShares(shares)
+}
@@ -0,0 +1,115 @@
+package shamir
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAccumulator(t *testing.T) {
+	secret := []byte{0xde, 0xca, 0xfb, 0xad}
+
+	shares, err := Split(3, 5, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := NewAccumulator(3)
+	if a.Ready() || a.Needed() != 3 {
+		t.Fatalf("expected 3 shares needed, got %d", a.Needed())
+	}
+
+	for i, share := range shares[:3] {
+		if err := a.Add(share); err != nil {
+			t.Fatal(err)
+		}
+		if a.Needed() != 2-i {
+			t.Fatalf("expected %d shares needed, got %d", 2-i, a.Needed())
+		}
+
+		// duplicates are rejected right away
+		if err := a.Add(share); err == nil {
+			t.Fatal("expected error for duplicate share")
+		}
+	}
+
+	if !a.Ready() {
+		t.Fatal("expected accumulator to be ready")
+	}
+
+	// surplus shares are fine
+	if err := a.Add(shares[3]); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := a.Combine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, secret) {
+		t.Fatalf("expected %x, got %x", secret, got)
+	}
+}
+
+func TestAccumulator_Add(t *testing.T) {
+	tests := []struct {
+		name    string
+		shares  [][]byte
+		wantErr bool
+	}{
+		{name: "short", shares: [][]byte{{0, 1}}, wantErr: true},
+		{name: "bad padding", shares: [][]byte{{0, 1, 2, 3, 4}}, wantErr: true},
+		{name: "zero x", shares: [][]byte{{0, 0, 2, 3}}, wantErr: true},
+		{name: "length mismatch", shares: [][]byte{{0, 1, 2, 3}, {0, 2, 2, 3, 4, 5}}, wantErr: true},
+		{name: "duplicate", shares: [][]byte{{0, 1, 2, 3}, {0, 1, 4, 5}}, wantErr: true},
+		{name: "valid", shares: [][]byte{{0, 1, 2, 3}, {0, 2, 4, 5}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAccumulator(0)
+
+			var err error
+			for _, share := range tt.shares {
+				if err = a.Add(share); err != nil {
+					break
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if a.Needed() != -1 || a.Ready() {
+				t.Fatal("expected unknown threshold")
+			}
+		})
+	}
+}
+
+func TestAccumulator_header(t *testing.T) {
+	shares, err := Split(3, 5, []byte("hello, world!"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := Split(3, 5, []byte("hello, world!"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := NewAccumulator(0)
+	if a.Needed() != -1 {
+		t.Fatalf("expected unknown threshold, got %d", a.Needed())
+	}
+
+	if err := a.Add(shares[0]); err != nil {
+		t.Fatal(err)
+	}
+	if a.Needed() != 2 {
+		t.Fatalf("expected 2 shares needed, got %d", a.Needed())
+	}
+
+	if err := a.Add(other[1]); err == nil {
+		t.Fatal("expected error for share of a different dealing")
+	}
+
+	if err := NewAccumulator(2).Add(shares[0]); err == nil {
+		t.Fatal("expected error for threshold mismatch")
+	}
+}
@@ -0,0 +1,154 @@
+package shamir
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/wbrc/gf65536"
+)
+
+// Combiner recovers secrets from the shares of a fixed group of holders. It is
+// bound to the x coordinates of the holders and caches the interpolation
+// weights for them, so that repeated reconstructions from the same quorum skip
+// the setup work. A Combiner is safe for concurrent use.
+type Combiner struct {
+	f         gf65536.Field
+	byteOrder binary.ByteOrder
+	workers   int
+	xvals     []uint16
+	weights   []uint16
+}
+
+// NewCombiner returns a Combiner for the holders with the given x coordinates.
+// The x coordinates must be distinct and nonzero.
+func (d *Dealer) NewCombiner(xvals []uint16) (*Combiner, error) {
+	d.init()
+
+	if len(xvals) == 0 {
+		return nil, ErrNoShares
+	}
+
+	err := checkXes(xvals)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Combiner{
+		f:         d.F,
+		byteOrder: d.ByteOrder,
+		workers:   d.Concurrency,
+		xvals:     append([]uint16(nil), xvals...),
+		weights:   make([]uint16, len(xvals)),
+	}
+
+	err = lagrange(c.f, c.weights, c.xvals, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// NewCombiner returns a Combiner using the default dealer.
+func NewCombiner(xvals []uint16) (*Combiner, error) {
+	return Default.NewCombiner(xvals)
+}
+
+// X returns the x coordinates the Combiner is bound to.
+func (c *Combiner) X() []uint16 {
+	return append([]uint16(nil), c.xvals...)
+}
+
+// CombineShares recovers a secret from the holders' shares, as returned by
+// Dealer.SplitShares or decoded with Dealer.ParseShare. shares[i] must belong
+// to the holder with the i-th x coordinate the Combiner was created with. The
+// shares must be from the same dealing in the field of the Combiner, and as
+// with Dealer.CombineShares, their padding and digest are checked.
+func (c *Combiner) CombineShares(shares []Share) ([]byte, error) {
+	if len(shares) != len(c.xvals) {
+		return nil, fmt.Errorf("%w: have %d shares for %d x coordinates", ErrParamsMismatch, len(shares), len(c.xvals))
+	}
+
+	h, wordShares, err := checkShares(shares)
+	if err != nil {
+		return nil, err
+	}
+	for i, s := range shares {
+		if s.X() != c.xvals[i] {
+			return nil, &ShareError{Index: i, Err: fmt.Errorf("%w: x coordinate %d, expected %d", ErrParamsMismatch, s.X(), c.xvals[i])}
+		}
+	}
+	if h.field != c.f {
+		return nil, fmt.Errorf("%w: shares are in field %#x, not %#x", ErrParamsMismatch, h.field, c.f)
+	}
+
+	err = checkRoots(shares)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(shares) < h.threshold {
+		return nil, fmt.Errorf("%w: have %d shares, need %d", ErrInsufficientOrInvalidShares, len(shares), h.threshold)
+	}
+
+	secretWords := make([]uint16, len(wordShares[0])-1)
+	parallel(c.workers, len(secretWords), func(lo, hi int) {
+		yvals := make([]uint16, len(wordShares))
+		for w := lo + 1; w <= hi; w++ {
+			for r := range wordShares {
+				yvals[r] = wordShares[r][w]
+			}
+
+			secretWords[w-1] = dot(c.f, c.weights, yvals)
+		}
+	})
+
+	return decodeSecret(h, secretWords, shares[0].length)
+}
+
+// Combine recovers a secret from the raw y values of the holders' shares, in
+// the byte order of the Combiner. yShares[i] must belong to the holder with the
+// i-th x coordinate the Combiner was created with. The result is not checked
+// and includes any padding; use CombineShares for encoded shares.
+func (c *Combiner) Combine(yShares [][]byte) ([]byte, error) {
+	if len(yShares) == 0 {
+		return nil, ErrNoShares
+	}
+
+	secret := make([]byte, len(yShares[0]))
+	err := c.CombineInto(secret, yShares)
+	if err != nil {
+		return nil, err
+	}
+
+	return secret, nil
+}
+
+// CombineInto is like Combine but writes the secret to dst, which must have the
+// same length as the y values.
+func (c *Combiner) CombineInto(dst []byte, yShares [][]byte) error {
+	if len(yShares) != len(c.xvals) {
+		return fmt.Errorf("%w: have %d shares for %d x coordinates", ErrParamsMismatch, len(yShares), len(c.xvals))
+	}
+
+	for i, y := range yShares {
+		if len(y) != len(dst) {
+			return &ShareError{Index: i, Err: ErrLengthMismatch}
+		}
+	}
+	if len(dst)%2 != 0 {
+		return fmt.Errorf("%w: share must be a multiple of 2 bytes", ErrMalformedShare)
+	}
+
+	parallel(c.workers, len(dst)/2, func(lo, hi int) {
+		for i := lo * 2; i < hi*2; i += 2 {
+			var secret uint16
+			for r, y := range yShares {
+				secret = c.f.Add(secret, c.f.Mul(c.weights[r], c.byteOrder.Uint16(y[i:])))
+			}
+			c.byteOrder.PutUint16(dst[i:], secret)
+		}
+	})
+
+	return nil
+}
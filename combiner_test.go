@@ -0,0 +1,184 @@
+package shamir
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/wbrc/gf65536"
+)
+
+func TestCombiner(t *testing.T) {
+	var d Dealer
+
+	xvals := []uint16{0x1234, 0xbeef, 0x0042}
+
+	c, err := d.NewCombiner(xvals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the same holders reconstruct several different secrets
+	for range 5 {
+		secret := make([]uint16, 16)
+		err := binary.Read(rand.Reader, binary.BigEndian, secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		yShares := make([][]byte, len(xvals))
+		for i := range yShares {
+			yShares[i] = make([]byte, len(secret)*2)
+		}
+
+		ys := make([][]uint16, len(xvals))
+		for i := range ys {
+			ys[i] = make([]uint16, len(secret))
+		}
+		err = splitWords(f, rand.Reader, len(xvals), xvals, secret, ys, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for j := range yShares {
+			_, err = binary.Encode(yShares[j], binary.BigEndian, ys[j])
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		got, err := c.Combine(yShares)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := make([]byte, len(secret)*2)
+		_, err = binary.Encode(want, binary.BigEndian, secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("expected %x, got %x", want, got)
+		}
+	}
+}
+
+func TestCombiner_CombineShares(t *testing.T) {
+	d := Dealer{Digest: true}
+	secret := []byte("quorum secret")
+
+	shares, err := d.SplitShares(3, 5, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	quorum := shares[1:4]
+	xvals := make([]uint16, len(quorum))
+	for i, s := range quorum {
+		xvals[i] = s.X()
+	}
+
+	c, err := d.NewCombiner(xvals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.CombineShares(quorum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, secret) {
+		t.Fatalf("expected %q, got %q", secret, got)
+	}
+
+	other, err := d.SplitShares(3, 5, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	field, err := gf65536.New(0x1100b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherField, err := (&Dealer{F: field}).NewCombiner(xvals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pair, err := d.NewCombiner(xvals[:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		combiner *Combiner
+		shares   []Share
+		wantErr  error
+	}{
+		{name: "share count", combiner: c, shares: quorum[:2], wantErr: ErrParamsMismatch},
+		{name: "order", combiner: c, shares: []Share{quorum[1], quorum[0], quorum[2]}, wantErr: ErrParamsMismatch},
+		{name: "different dealings", combiner: c, shares: []Share{quorum[0], quorum[1], other[3]}, wantErr: ErrDifferentDealing},
+		{name: "field", combiner: otherField, shares: quorum, wantErr: ErrParamsMismatch},
+		{name: "below threshold", combiner: pair, shares: quorum[:2], wantErr: ErrInsufficientOrInvalidShares},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.combiner.CombineShares(tt.shares)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestNewCombiner(t *testing.T) {
+	tests := []struct {
+		name    string
+		xvals   []uint16
+		wantErr bool
+	}{
+		{name: "nil", xvals: nil, wantErr: true},
+		{name: "zero", xvals: []uint16{1, 0, 3}, wantErr: true},
+		{name: "duplicate", xvals: []uint16{1, 2, 1}, wantErr: true},
+		{name: "valid", xvals: []uint16{1, 2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewCombiner(tt.xvals)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCombiner_CombineInto(t *testing.T) {
+	c, err := NewCombiner([]uint16{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		dst     []byte
+		yShares [][]byte
+		wantErr error
+	}{
+		{name: "share count", dst: make([]byte, 2), yShares: [][]byte{{1, 2}}, wantErr: ErrParamsMismatch},
+		{name: "inconsistent", dst: make([]byte, 2), yShares: [][]byte{{1, 2}, {1, 2, 3, 4}}, wantErr: ErrLengthMismatch},
+		{name: "dst length", dst: make([]byte, 4), yShares: [][]byte{{1, 2}, {1, 2}}, wantErr: ErrLengthMismatch},
+		{name: "odd", dst: make([]byte, 3), yShares: [][]byte{{1, 2, 3}, {1, 2, 3}}, wantErr: ErrMalformedShare},
+		{name: "valid", dst: make([]byte, 2), yShares: [][]byte{{1, 2}, {1, 2}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.CombineInto(tt.dst, tt.yShares)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package shamir
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+)
+
+// length of the digest split along with a secret
+const digestSize = 16
+
+// return the digest of a secret keyed with the set ID of its dealing
+func digest(setID [8]byte, secret []byte) []byte {
+	mac := hmac.New(sha256.New, setID[:])
+	mac.Write(secret)
+
+	return mac.Sum(nil)[:digestSize]
+}
+
+// check that a recovered secret, followed by its padding and its digest,
+// matches the digest and return the secret
+func checkDigest(setID [8]byte, recovered []byte, length int) ([]byte, error) {
+	if len(recovered) < digestSize {
+		return nil, ErrInsufficientOrInvalidShares
+	}
+
+	d := recovered[len(recovered)-digestSize:]
+	secret, err := unpad(recovered[:len(recovered)-digestSize], length)
+	if err != nil || !hmac.Equal(d, digest(setID, secret)) {
+		return nil, ErrInsufficientOrInvalidShares
+	}
+
+	return secret, nil
+}
@@ -0,0 +1,43 @@
+package shamir
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestDealer_Digest(t *testing.T) {
+	d := Dealer{Digest: true}
+
+	for _, secret := range [][]byte{[]byte("hello, world!!"), []byte("hello, world!")} {
+		shares, err := d.Split(3, 5, secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := d.Combine(shares[2:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, secret) {
+			t.Fatalf("expected %q, got %q", secret, got)
+		}
+
+		// a corrupted y word
+		corrupted := [][]byte{shares[0], shares[1], bytes.Clone(shares[2])}
+		corrupted[2][len(corrupted[2])-digestSize-1] ^= 1
+		if _, err := d.Combine(corrupted); !errors.Is(err, ErrInsufficientOrInvalidShares) {
+			t.Fatalf("expected ErrInsufficientOrInvalidShares, got %v", err)
+		}
+
+		// too few shares, with the recorded threshold forged to match
+		forged := [][]byte{withByte(shares[0], 7, 2), withByte(shares[1], 7, 2)}
+		if _, err := d.Combine(forged); !errors.Is(err, ErrInsufficientOrInvalidShares) {
+			t.Fatalf("expected ErrInsufficientOrInvalidShares, got %v", err)
+		}
+
+		if _, err := d.Combine(shares[:2]); !errors.Is(err, ErrInsufficientOrInvalidShares) {
+			t.Fatalf("expected ErrInsufficientOrInvalidShares, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package shamir
+
+import (
+	"fmt"
+)
+
+// ShareAt issues a new share with x coordinate x from existing shares, e.g.
+// for a new holder, without re-dealing the secret. The polynomials through the
+// shares are evaluated at x instead of at 0, so the secret is never recovered
+// in the process, though whoever runs ShareAt sees enough shares to recover
+// it. The new share is from the same dealing as the existing ones.
+//
+// If the shares record the threshold, there must be at least threshold shares
+// and the first threshold shares are used. Otherwise all shares are used, and
+// if there are fewer than the threshold, the new share is invalid. x must be
+// nonzero and must not be the x coordinate of any of the shares; it is up to
+// the caller to make sure that no other holder has a share at x. Shares of
+// dealings with a manifest can not be issued, since the new share would not be
+// part of the manifest.
+func (d *Dealer) ShareAt(x uint16, shares [][]byte) ([]byte, error) {
+	d.init()
+
+	parsed, err := d.parseShares(shares)
+	if err != nil {
+		return nil, err
+	}
+
+	h, wordShares, err := checkShares(parsed)
+	if err != nil {
+		return nil, err
+	}
+	if h.manifest {
+		return nil, fmt.Errorf("%w: can not issue shares of a dealing with a manifest", ErrInvalidParams)
+	}
+	if len(shares) < h.threshold {
+		return nil, fmt.Errorf("%w: have %d shares, need %d", ErrInsufficientOrInvalidShares, len(shares), h.threshold)
+	}
+	if x == 0 {
+		return nil, ErrZeroX
+	}
+	for i, share := range wordShares {
+		if share[0] == x {
+			return nil, fmt.Errorf("%w: x coordinate %d is used by share %d", ErrDuplicateShare, x, i)
+		}
+	}
+
+	if h.threshold > 0 {
+		wordShares = wordShares[:h.threshold]
+	}
+
+	ys, err := interpolate(h.field, wordShares, x, d.Concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	s := Share{
+		header: h,
+		words:  append([]uint16{x}, ys...),
+		length: parsed[0].length,
+	}
+
+	return s.MarshalBinary()
+}
+
+// ShareAt issues a new share using the default dealer.
+func ShareAt(x uint16, shares [][]byte) ([]byte, error) {
+	return Default.ShareAt(x, shares)
+}
@@ -0,0 +1,82 @@
+package shamir
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestShareAt(t *testing.T) {
+	secret := []byte("new custodian")
+
+	for _, digest := range []bool{false, true} {
+		d := Dealer{Digest: digest}
+
+		shares, err := d.Split(3, 5, secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		x := uint16(0x1234)
+		share, err := d.ShareAt(x, shares[:3])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		s, err := d.ParseShare(share)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.X() != x {
+			t.Fatalf("expected x coordinate %d, got %d", x, s.X())
+		}
+
+		// the new share combines with shares that were not used to issue it
+		got, err := d.Combine([][]byte{share, shares[3], shares[4]})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, secret) {
+			t.Fatalf("expected %q, got %q", secret, got)
+		}
+	}
+}
+
+func TestShareAt_invalid(t *testing.T) {
+	shares, err := Split(3, 5, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	used, err := Default.ParseShare(shares[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifestShares, _, err := SplitManifest(2, 3, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		x      uint16
+		shares [][]byte
+		want   error
+	}{
+		{name: "zero x", x: 0, shares: shares, want: ErrZeroX},
+		{name: "used x", x: used.X(), shares: shares, want: ErrDuplicateShare},
+		{name: "insufficient", x: 1, shares: shares[:2], want: ErrInsufficientOrInvalidShares},
+		{name: "nil shares", x: 1, want: ErrNoShares},
+		{name: "manifest", x: 1, shares: manifestShares},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ShareAt(tt.x, tt.shares)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package shamir
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned for invalid parameters and shares. They are usually wrapped,
+// e.g. in a *ShareError that records the index of the offending share, so test
+// for them with errors.Is.
+var (
+	ErrThresholdTooLarge = errors.New("threshold must be less than or equal to n")
+	ErrThresholdTooSmall = errors.New("threshold must be greater than 0")
+	ErrThresholdMismatch = errors.New("threshold mismatch")
+	ErrShareCount        = errors.New("n must be between 1 and 65535")
+	ErrEmptySecret       = errors.New("nil secret")
+	ErrSecretTooLong     = errors.New("secret too long")
+	ErrNoShares          = errors.New("nil shares")
+	ErrMalformedShare    = errors.New("malformed share")
+	ErrZeroX             = errors.New("x coordinate must be nonzero")
+	ErrDuplicateShare    = errors.New("duplicate x coordinate")
+	ErrLengthMismatch    = errors.New("inconsistent share length")
+	ErrDifferentDealing  = errors.New("share is from a different dealing")
+	ErrParamsMismatch    = errors.New("inconsistent share parameters")
+	ErrManifestMismatch  = errors.New("share is not part of the dealing manifest")
+	ErrSingularMatrix    = errors.New("interpolation matrix of the shares is singular")
+	ErrInvalidParams     = errors.New("invalid parameters")
+	ErrMalformedManifest = errors.New("malformed manifest")
+
+	ErrMalformedCommitments = errors.New("malformed commitments")
+	ErrCommitmentMismatch   = errors.New("share does not match the commitments")
+
+	// ErrInsufficientOrInvalidShares is returned when combining shares does
+	// not recover the secret, because there are fewer shares than the
+	// threshold or some shares are corrupted. Without a digest (see
+	// Dealer.Digest) it can only be detected if the shares record the
+	// threshold.
+	ErrInsufficientOrInvalidShares = errors.New("insufficient or invalid shares")
+)
+
+// ShareError records an error caused by a single share, identified by its
+// index in the shares passed to the failing call.
+type ShareError struct {
+	Index int
+	Err   error
+}
+
+func (e *ShareError) Error() string {
+	return fmt.Sprintf("share %d: %v", e.Index, e.Err)
+}
+
+func (e *ShareError) Unwrap() error {
+	return e.Err
+}
+
+// DuplicateShareError is returned if two shares have the same x coordinate,
+// e.g. because the same share was passed twice. I and J are the indices of the
+// shares, I < J. It matches ErrDuplicateShare.
+type DuplicateShareError struct {
+	I, J int
+	X    uint16
+}
+
+func (e *DuplicateShareError) Error() string {
+	return fmt.Sprintf("shares %d and %d have the same x coordinate %d", e.I, e.J, e.X)
+}
+
+func (e *DuplicateShareError) Is(target error) bool {
+	return target == ErrDuplicateShare
+}
+
+// check that x coordinates are nonzero and distinct
+func checkXes(xvals []uint16) error {
+	seen := make(map[uint16]int, len(xvals))
+	for i, x := range xvals {
+		if x == 0 {
+			return &ShareError{Index: i, Err: ErrZeroX}
+		}
+		if j, ok := seen[x]; ok {
+			return &DuplicateShareError{I: j, J: i, X: x}
+		}
+		seen[x] = i
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package shamir
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSplit_errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+		n         int
+		secret    []byte
+		want      error
+	}{
+		{name: "threshold too large", threshold: 4, n: 3, secret: []byte("secret"), want: ErrThresholdTooLarge},
+		{name: "zero threshold", threshold: 0, n: 3, secret: []byte("secret"), want: ErrThresholdTooSmall},
+		{name: "too many shares", threshold: 2, n: 65536, secret: []byte("secret"), want: ErrShareCount},
+		{name: "nil secret", threshold: 2, n: 3, want: ErrEmptySecret},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Split(tt.threshold, tt.n, tt.secret)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestCombine_errors(t *testing.T) {
+	shares, err := Split(2, 3, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := Split(2, 3, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		shares [][]byte
+		want   error
+		index  int // index of the share in the *ShareError, -1 for none
+	}{
+		{name: "nil shares", want: ErrNoShares, index: -1},
+		{name: "malformed", shares: [][]byte{shares[0], shares[1][:headerSize-1]}, want: ErrMalformedShare, index: 1},
+		{name: "zero x", shares: [][]byte{shares[0], withByte(withByte(shares[1], headerSize+4, 0), headerSize+5, 0)}, want: ErrZeroX, index: 1},
+		{name: "different dealing", shares: [][]byte{shares[0], shares[1], other[2]}, want: ErrDifferentDealing, index: 2},
+		{name: "threshold mismatch", shares: [][]byte{shares[0], withByte(shares[1], 7, 3)}, want: ErrParamsMismatch, index: 1},
+		{name: "insufficient", shares: shares[:1], want: ErrInsufficientOrInvalidShares, index: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Combine(tt.shares)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+
+			var serr *ShareError
+			if errors.As(err, &serr) != (tt.index >= 0) {
+				t.Fatalf("unexpected share error: %v", err)
+			}
+			if tt.index >= 0 && serr.Index != tt.index {
+				t.Fatalf("expected share %d, got %d", tt.index, serr.Index)
+			}
+		})
+	}
+}
+
+func TestCombine_duplicate(t *testing.T) {
+	shares, err := Split(2, 3, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Combine([][]byte{shares[0], shares[1], shares[0]})
+	if !errors.Is(err, ErrDuplicateShare) {
+		t.Fatalf("expected %v, got %v", ErrDuplicateShare, err)
+	}
+
+	var derr *DuplicateShareError
+	if !errors.As(err, &derr) {
+		t.Fatalf("expected *DuplicateShareError, got %T", err)
+	}
+	if derr.I != 0 || derr.J != 2 {
+		t.Fatalf("expected shares 0 and 2, got %d and %d", derr.I, derr.J)
+	}
+}
+
+func Test_combine_errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		shares [][]uint16
+		want   error
+	}{
+		{name: "duplicate", shares: [][]uint16{{1, 5}, {2, 6}, {1, 5}}, want: ErrDuplicateShare},
+		{name: "zero x", shares: [][]uint16{{1, 5}, {0, 6}}, want: ErrZeroX},
+		{name: "length mismatch", shares: [][]uint16{{1, 5}, {2, 6, 7}}, want: ErrLengthMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := combine(defaultField, tt.shares, 1)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
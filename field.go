@@ -0,0 +1,65 @@
+package shamir
+
+import (
+	"filippo.io/edwards25519"
+	"github.com/wbrc/gf65536"
+)
+
+// a finite field with elements of type E, such that the polynomial helpers can
+// be used for fields other than GF(2^16)
+type field[E any] interface {
+	Zero() E
+	One() E
+	Add(a, b E) E
+	Sub(a, b E) E
+	Mul(a, b E) E
+	Inv(a E) E
+	IsZero(a E) bool
+}
+
+// GF(2^16) as a field
+type gf16 struct {
+	gf65536.Field
+}
+
+var _ field[uint16] = gf16{}
+
+func (gf16) Zero() uint16 { return 0 }
+
+func (gf16) One() uint16 { return 1 }
+
+// in characteristic 2, subtraction is addition
+func (f gf16) Sub(a, b uint16) uint16 { return f.Add(a, b) }
+
+func (gf16) IsZero(a uint16) bool { return a == 0 }
+
+// the scalar field of the prime-order subgroup of edwards25519, with
+// constant-time arithmetic
+type scalarField struct{}
+
+var _ field[*edwards25519.Scalar] = scalarField{}
+
+func (scalarField) Zero() *edwards25519.Scalar { return edwards25519.NewScalar() }
+
+func (scalarField) One() *edwards25519.Scalar { return scalarFromX(1) }
+
+func (scalarField) Add(a, b *edwards25519.Scalar) *edwards25519.Scalar {
+	return edwards25519.NewScalar().Add(a, b)
+}
+
+func (scalarField) Sub(a, b *edwards25519.Scalar) *edwards25519.Scalar {
+	return edwards25519.NewScalar().Subtract(a, b)
+}
+
+func (scalarField) Mul(a, b *edwards25519.Scalar) *edwards25519.Scalar {
+	return edwards25519.NewScalar().Multiply(a, b)
+}
+
+// the inverse of 0 is 0
+func (scalarField) Inv(a *edwards25519.Scalar) *edwards25519.Scalar {
+	return edwards25519.NewScalar().Invert(a)
+}
+
+func (scalarField) IsZero(a *edwards25519.Scalar) bool {
+	return a.Equal(edwards25519.NewScalar()) == 1
+}
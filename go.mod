@@ -5,6 +5,7 @@ go 1.24.0
 toolchain go1.24.1
 
 require (
+	filippo.io/edwards25519 v1.2.0
 	github.com/wbrc/gf65536 v1.0.0
 	golang.org/x/crypto v0.42.0
 )
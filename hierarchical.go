@@ -0,0 +1,471 @@
+package shamir
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sync/atomic"
+
+	"filippo.io/edwards25519"
+)
+
+// Level is a level of a hierarchical dealing, see SplitHierarchical.
+type Level struct {
+	// Threshold is the number of shares needed from this and all higher
+	// levels. It is cumulative, so it must increase from level to level.
+	Threshold int
+
+	// N is the number of participants on this level.
+	N int
+}
+
+// The binary encoding of a share of a hierarchical dealing is
+//
+//	magic [4]byte | version uint8 | flags uint8 | levels uint8 |
+//	thresholds [levels]uint16 | level uint8 | set ID [8]byte |
+//	length uint32 | x uint16 | y [chunks][32]byte
+//
+// where thresholds are the thresholds of the levels of the dealing, level is
+// the level of the participant and length is the length of the secret in
+// bytes. The secret is padded as by Dealer.SplitShares, followed by its digest
+// if flag bit 1 is set, and split in chunks of hierarchicalChunkSize bytes,
+// the last one filled up with zero bytes. y holds the canonical encodings of
+// the values at x of the derivatives of order thresholds[level-1] of the
+// polynomials of the chunks, or of the polynomials themselves for level 0.
+// All integers are big-endian.
+var hierarchicalMagic = [4]byte{'S', 'H', 'M', 'H'}
+
+const (
+	hierarchicalVersion = 2
+
+	// number of secret bytes per chunk, such that a chunk fits into a scalar
+	// below the order of the group
+	hierarchicalChunkSize = 31
+)
+
+// share of a hierarchical dealing
+type hierarchicalShare struct {
+	thresholds []int
+	level      int
+	digest     bool
+	setID      [8]byte
+	length     int
+	x          uint16
+	y          []*edwards25519.Scalar
+}
+
+// SplitHierarchical splits a secret with Tassa's hierarchical threshold
+// scheme. levels[0] is the highest level. A set of participants can recover
+// the secret if, for every level, it has at least the threshold of the level
+// in shares of that or higher levels, e.g.
+//
+//	[]Level{{Threshold: 1, N: 2}, {Threshold: 3, N: 5}}
+//
+// needs 3 shares, at least 1 of which from one of the 2 participants on the
+// top level. The secret is the constant term of polynomials of degree less
+// than the threshold of the last level. Participants on level 0 get shares as
+// usual, participants on a lower level get the derivative of the order of the
+// threshold of the level above. The shares are returned level by level.
+//
+// Reconstruction uses Birkhoff interpolation, which unlike Lagrange
+// interpolation can fail for some sets of participants even if they are
+// authorized. It is done in the scalar field of edwards25519, like
+// VSSDealer, since over GF(2^16) many derivatives vanish and common policies
+// such as the one above never work. The participants get the x coordinates 1
+// to n level by level, for which Tassa shows that every authorized set can
+// combine if the field is large enough compared to the number of participants
+// and the threshold; CombineHierarchical reports the remaining cases with an
+// error matching ErrSingularMatrix. The field and byte order of the dealer are
+// not used.
+func (d *Dealer) SplitHierarchical(levels []Level, secret []byte) ([][]byte, error) {
+	d.init()
+
+	err := checkLevels(levels)
+	if err != nil {
+		return nil, err
+	}
+	if len(secret) == 0 {
+		return nil, ErrEmptySecret
+	}
+	if len(secret) > math.MaxUint32 {
+		return nil, ErrSecretTooLong
+	}
+
+	thresholds := make([]int, len(levels))
+	for i, l := range levels {
+		thresholds[i] = l.Threshold
+	}
+	threshold := thresholds[len(thresholds)-1]
+
+	hs := hierarchicalShare{thresholds: thresholds, digest: d.Digest, length: len(secret)}
+	_, err = io.ReadFull(d.Rand, hs.setID[:])
+	if err != nil {
+		return nil, err
+	}
+
+	data := pad(secret, digestSize)
+	if hs.digest {
+		data = append(data, digest(hs.setID, secret)...)
+	}
+	chunks := hierarchicalChunks(len(data))
+
+	// the row of every participant depends only on its x coordinate and
+	// level, so it is computed once for all chunks
+	var rows [][]*edwards25519.Scalar
+	var out []hierarchicalShare
+	for level, l := range levels {
+		for range l.N {
+			s := hs
+			s.level = level
+			s.x = uint16(len(out) + 1)
+			s.y = make([]*edwards25519.Scalar, chunks)
+			out = append(out, s)
+			rows = append(rows, derivativePows(threshold, scalarFromX(s.x), derivativeOrder(thresholds, level)))
+		}
+	}
+
+	var f scalarField
+	polynomial := make([]*edwards25519.Scalar, threshold)
+	var buf [64]byte
+	for c := range chunks {
+		// the chunk in the low bytes and the top byte zero, so the scalar is
+		// canonical
+		clear(buf[:])
+		copy(buf[:hierarchicalChunkSize], data[c*hierarchicalChunkSize:])
+		polynomial[0], err = edwards25519.NewScalar().SetCanonicalBytes(buf[:32])
+		if err != nil {
+			return nil, err
+		}
+
+		for j := 1; j < threshold; j++ {
+			_, err = io.ReadFull(d.Rand, buf[:])
+			if err != nil {
+				return nil, err
+			}
+			polynomial[j], err = edwards25519.NewScalar().SetUniformBytes(buf[:])
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for i, row := range rows {
+			out[i].y[c] = dotIn(f, row, polynomial)
+		}
+	}
+	clear(buf[:])
+	clear(data)
+
+	shares := make([][]byte, len(out))
+	for i, s := range out {
+		shares[i] = s.append(nil)
+	}
+
+	return shares, nil
+}
+
+// CombineHierarchical recovers a secret from shares of a hierarchical dealing
+// created by SplitHierarchical. If the shares are not authorized, it returns
+// an error matching ErrInsufficientOrInvalidShares that names the first level
+// with too few shares. If they are authorized but the Birkhoff interpolation
+// matrix of their x coordinates is singular, it returns an error matching
+// ErrSingularMatrix.
+func (d *Dealer) CombineHierarchical(shares [][]byte) ([]byte, error) {
+	d.init()
+
+	if len(shares) == 0 {
+		return nil, ErrNoShares
+	}
+
+	parsed := make([]hierarchicalShare, len(shares))
+	xs := make([]uint16, len(shares))
+	for i, b := range shares {
+		s, err := parseHierarchical(b)
+		if err != nil {
+			return nil, &ShareError{Index: i, Err: err}
+		}
+
+		first := parsed[0]
+		if i == 0 {
+			first = s
+		}
+		if !slices.Equal(s.thresholds, first.thresholds) {
+			return nil, &ShareError{Index: i, Err: fmt.Errorf("%w: different levels", ErrParamsMismatch)}
+		}
+		if s.setID != first.setID {
+			return nil, &ShareError{Index: i, Err: ErrDifferentDealing}
+		}
+		if s.digest != first.digest {
+			return nil, &ShareError{Index: i, Err: ErrParamsMismatch}
+		}
+		if s.length != first.length {
+			return nil, &ShareError{Index: i, Err: ErrLengthMismatch}
+		}
+
+		parsed[i] = s
+		xs[i] = s.x
+	}
+
+	err := checkXes(xs)
+	if err != nil {
+		return nil, err
+	}
+
+	// for every level, there must be enough shares of that or higher levels
+	thresholds := parsed[0].thresholds
+	for level, t := range thresholds {
+		have := 0
+		for _, s := range parsed {
+			if s.level <= level {
+				have++
+			}
+		}
+		if have < t {
+			return nil, fmt.Errorf("%w: level %d: have %d shares of this or higher levels, need %d", ErrInsufficientOrInvalidShares, level, have, t)
+		}
+	}
+
+	weights, err := birkhoff(thresholds, parsed)
+	if err != nil {
+		return nil, err
+	}
+
+	var f scalarField
+	h := parsed[0]
+	data := make([]byte, len(h.y)*hierarchicalChunkSize)
+	var invalid atomic.Bool
+	parallel(d.Concurrency, len(h.y), func(lo, hi int) {
+		yvals := make([]*edwards25519.Scalar, len(parsed))
+		for c := lo; c < hi; c++ {
+			for r, s := range parsed {
+				yvals[r] = s.y[c]
+			}
+
+			b := dotIn(f, weights, yvals).Bytes()
+			if b[31] != 0 {
+				invalid.Store(true)
+			}
+			copy(data[c*hierarchicalChunkSize:(c+1)*hierarchicalChunkSize], b)
+			clear(b)
+		}
+	})
+	if invalid.Load() {
+		return nil, ErrInsufficientOrInvalidShares
+	}
+
+	// strip the zero bytes filling up the last chunk
+	size := paddedLen(h.length)
+	if h.digest {
+		size += digestSize
+	}
+	data, err = trimZeros(data, size)
+	if err != nil {
+		return nil, err
+	}
+
+	if h.digest {
+		return checkDigest(h.setID, data, h.length)
+	}
+
+	return unpad(data, h.length)
+}
+
+// SplitHierarchical splits a secret with a hierarchical threshold using the
+// default dealer.
+func SplitHierarchical(levels []Level, secret []byte) ([][]byte, error) {
+	return Default.SplitHierarchical(levels, secret)
+}
+
+// CombineHierarchical combines shares of a hierarchical dealing using the
+// default dealer.
+func CombineHierarchical(shares [][]byte) ([]byte, error) {
+	return Default.CombineHierarchical(shares)
+}
+
+func checkLevels(levels []Level) error {
+	if len(levels) == 0 || len(levels) > 255 {
+		return fmt.Errorf("%w: number of levels must be between 1 and 255", ErrInvalidParams)
+	}
+
+	n := 0
+	for i, l := range levels {
+		if l.Threshold < 1 {
+			return fmt.Errorf("level %d: %w", i, ErrThresholdTooSmall)
+		}
+		if i > 0 && l.Threshold <= levels[i-1].Threshold {
+			return fmt.Errorf("%w: level %d: threshold must be greater than the threshold of the level above", ErrInvalidParams, i)
+		}
+		if l.N < 1 {
+			return fmt.Errorf("%w: level %d: number of participants must be greater than 0", ErrInvalidParams, i)
+		}
+
+		// otherwise no set of participants is authorized
+		n += l.N
+		if l.Threshold > n {
+			return fmt.Errorf("level %d: %w", i, ErrThresholdTooLarge)
+		}
+		if n > math.MaxUint16 {
+			return ErrShareCount
+		}
+	}
+
+	return nil
+}
+
+// return the order of the derivative the participants on a level get
+func derivativeOrder(thresholds []int, level int) int {
+	if level == 0 {
+		return 0
+	}
+
+	return thresholds[level-1]
+}
+
+// return the k-th derivatives of [x^0, x^1, ..., x^(n-1)], that is
+// v[j] = j!/(j-k)! * x^(j-k), such that dot(v, a) is the k-th derivative of
+// the polynomial with coefficients a at x
+func derivativePows(n int, x *edwards25519.Scalar, k int) []*edwards25519.Scalar {
+	var f scalarField
+	v := make([]*edwards25519.Scalar, n)
+	p := f.One()
+	for j := range v {
+		if j < k {
+			v[j] = f.Zero()
+			continue
+		}
+
+		// j * (j-1) * ... * (j-k+1)
+		c := f.One()
+		for i := j - k + 1; i <= j; i++ {
+			c = f.Mul(c, scalarFromX(uint16(i)))
+		}
+
+		v[j] = f.Mul(c, p)
+		p = f.Mul(p, x)
+	}
+
+	return v
+}
+
+// return weights w such that the constant term of a polynomial of degree less
+// than the threshold of the last level is dot(w, y), where y[i] is the value
+// of shares[i] for it
+func birkhoff(thresholds []int, shares []hierarchicalShare) ([]*edwards25519.Scalar, error) {
+	var f scalarField
+	threshold := thresholds[len(thresholds)-1]
+
+	// the Birkhoff matrix B has a row for every share, and w solves
+	// transpose(B) w = e0
+	m := make([][]*edwards25519.Scalar, threshold)
+	for j := range m {
+		m[j] = make([]*edwards25519.Scalar, len(shares)+1)
+		m[j][len(shares)] = f.Zero()
+	}
+	m[0][len(shares)] = f.One()
+
+	for i, s := range shares {
+		row := derivativePows(threshold, scalarFromX(s.x), derivativeOrder(thresholds, s.level))
+		for j := range m {
+			m[j][i] = row[j]
+		}
+	}
+
+	w, err := solveIn(f, m)
+	if err != nil {
+		return nil, ErrSingularMatrix
+	}
+
+	return w, nil
+}
+
+// number of chunks of a padded secret of the given length
+func hierarchicalChunks(length int) int {
+	return (length + hierarchicalChunkSize - 1) / hierarchicalChunkSize
+}
+
+func (s hierarchicalShare) append(b []byte) []byte {
+	var flags uint8
+	if s.digest {
+		flags |= flagDigest
+	}
+
+	b = append(b, hierarchicalMagic[:]...)
+	b = append(b, hierarchicalVersion, flags, uint8(len(s.thresholds)))
+	for _, t := range s.thresholds {
+		b = binary.BigEndian.AppendUint16(b, uint16(t))
+	}
+	b = append(b, uint8(s.level))
+	b = append(b, s.setID[:]...)
+	b = binary.BigEndian.AppendUint32(b, uint32(s.length))
+	b = binary.BigEndian.AppendUint16(b, s.x)
+	for _, y := range s.y {
+		b = append(b, y.Bytes()...)
+	}
+
+	return b
+}
+
+func parseHierarchical(b []byte) (hierarchicalShare, error) {
+	if len(b) < 7 || !bytes.HasPrefix(b, hierarchicalMagic[:]) {
+		return hierarchicalShare{}, fmt.Errorf("%w: not a share of a hierarchical dealing", ErrMalformedShare)
+	}
+	if b[4] != hierarchicalVersion {
+		return hierarchicalShare{}, fmt.Errorf("%w: unsupported version", ErrMalformedShare)
+	}
+	if b[5]&^flagDigest != 0 {
+		return hierarchicalShare{}, fmt.Errorf("%w: unsupported flags", ErrMalformedShare)
+	}
+
+	s := hierarchicalShare{digest: b[5]&flagDigest != 0}
+	n := int(b[6])
+	b = b[7:]
+	if n == 0 || len(b) < 2*n+1+8+4+2 {
+		return hierarchicalShare{}, fmt.Errorf("%w: share too short", ErrMalformedShare)
+	}
+
+	s.thresholds = make([]int, n)
+	for i := range s.thresholds {
+		s.thresholds[i] = int(binary.BigEndian.Uint16(b[2*i:]))
+		if s.thresholds[i] == 0 || (i > 0 && s.thresholds[i] <= s.thresholds[i-1]) {
+			return hierarchicalShare{}, fmt.Errorf("%w: invalid levels", ErrMalformedShare)
+		}
+	}
+	b = b[2*n:]
+
+	s.level = int(b[0])
+	if s.level >= n {
+		return hierarchicalShare{}, fmt.Errorf("%w: invalid level", ErrMalformedShare)
+	}
+	s.setID = [8]byte(b[1:9])
+	s.length = int(binary.BigEndian.Uint32(b[9:]))
+	s.x = binary.BigEndian.Uint16(b[13:])
+	b = b[15:]
+
+	if s.length < 1 {
+		return hierarchicalShare{}, fmt.Errorf("%w: nil secret", ErrMalformedShare)
+	}
+	if s.x == 0 {
+		return hierarchicalShare{}, ErrZeroX
+	}
+
+	size := paddedLen(s.length)
+	if s.digest {
+		size += digestSize
+	}
+	if len(b) != 32*hierarchicalChunks(size) {
+		return hierarchicalShare{}, fmt.Errorf("%w: length does not match secret length", ErrMalformedShare)
+	}
+
+	s.y = make([]*edwards25519.Scalar, len(b)/32)
+	for c := range s.y {
+		var err error
+		s.y[c], err = edwards25519.NewScalar().SetCanonicalBytes(b[32*c : 32*(c+1)])
+		if err != nil {
+			return hierarchicalShare{}, fmt.Errorf("%w: invalid y value for chunk %d", ErrMalformedShare, c)
+		}
+	}
+
+	return s, nil
+}
@@ -0,0 +1,225 @@
+package shamir
+
+import (
+	"bytes"
+	"errors"
+	mrand "math/rand/v2"
+	"testing"
+)
+
+func TestSplitHierarchical(t *testing.T) {
+	secret := []byte("two directors and two managers")
+	d := &Dealer{Rand: mrand.NewChaCha8([32]byte{})}
+
+	// 4 shares, at least 2 of which from directors
+	shares, err := d.SplitHierarchical([]Level{{Threshold: 2, N: 3}, {Threshold: 4, N: 4}}, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	directors, managers := shares[:3], shares[3:]
+
+	tests := []struct {
+		name    string
+		shares  [][]byte
+		wantErr error
+	}{
+		{name: "two directors and two managers", shares: [][]byte{directors[0], managers[1], directors[2], managers[3]}},
+		{name: "three directors and a manager", shares: [][]byte{directors[1], directors[0], managers[2], directors[2]}},
+		{name: "everyone", shares: shares},
+		{name: "one director and three managers", shares: [][]byte{directors[1], managers[0], managers[1], managers[2]}, wantErr: ErrInsufficientOrInvalidShares},
+		{name: "four managers", shares: managers, wantErr: ErrInsufficientOrInvalidShares},
+		{name: "three directors", shares: directors, wantErr: ErrInsufficientOrInvalidShares},
+		{name: "same share twice", shares: [][]byte{directors[0], directors[0], managers[0], managers[1]}, wantErr: ErrDuplicateShare},
+		{name: "plain share", shares: [][]byte{directors[0], directors[1][10:], managers[0], managers[1]}, wantErr: ErrMalformedShare},
+		{name: "nil shares", wantErr: ErrNoShares},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := d.CombineHierarchical(tt.shares)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, secret) {
+				t.Fatalf("expected %q, got %q", secret, got)
+			}
+		})
+	}
+}
+
+func TestSplitHierarchical_invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		levels []Level
+		want   error
+	}{
+		{name: "threshold too large", levels: []Level{{Threshold: 2, N: 1}, {Threshold: 3, N: 5}}, want: ErrThresholdTooLarge},
+		{name: "zero threshold", levels: []Level{{Threshold: 0, N: 2}, {Threshold: 3, N: 5}}, want: ErrThresholdTooSmall},
+		{name: "no levels", want: ErrInvalidParams},
+		{name: "decreasing threshold", levels: []Level{{Threshold: 2, N: 2}, {Threshold: 2, N: 5}}, want: ErrInvalidParams},
+		{name: "empty level", levels: []Level{{Threshold: 1, N: 2}, {Threshold: 3, N: 0}}, want: ErrInvalidParams},
+		{name: "too many participants", levels: []Level{{Threshold: 1, N: 2}, {Threshold: 3, N: 65534}}, want: ErrShareCount},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := SplitHierarchical(tt.levels, []byte("secret"))
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestSplitHierarchical_policies(t *testing.T) {
+	secret := []byte("director approval")
+	d := &Dealer{Rand: mrand.NewChaCha8([32]byte{})}
+
+	// over GF(2^16), the derivatives of these policies vanish, so some or all
+	// of their authorized sets could not combine
+	for _, levels := range [][]Level{
+		{{Threshold: 1, N: 2}, {Threshold: 3, N: 5}},
+		{{Threshold: 1, N: 1}, {Threshold: 3, N: 5}},
+		{{Threshold: 1, N: 3}, {Threshold: 4, N: 5}},
+		{{Threshold: 2, N: 4}, {Threshold: 5, N: 6}},
+		{{Threshold: 1, N: 2}, {Threshold: 3, N: 2}},
+		{{Threshold: 1, N: 3}, {Threshold: 2, N: 3}, {Threshold: 4, N: 5}},
+	} {
+		shares, err := d.SplitHierarchical(levels, secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var participants []int
+		for level, l := range levels {
+			for range l.N {
+				participants = append(participants, level)
+			}
+		}
+
+		// every set of participants combines iff it is authorized
+		for set := 1; set < 1<<len(shares); set++ {
+			var subset [][]byte
+			counts := make([]int, len(levels))
+			for i := range shares {
+				if set&(1<<i) != 0 {
+					subset = append(subset, shares[i])
+					counts[participants[i]]++
+				}
+			}
+
+			authorized, have := true, 0
+			for level, l := range levels {
+				have += counts[level]
+				authorized = authorized && have >= l.Threshold
+			}
+
+			got, err := d.CombineHierarchical(subset)
+			if !authorized {
+				if !errors.Is(err, ErrInsufficientOrInvalidShares) {
+					t.Fatalf("%v, set %b: expected %v, got %v", levels, set, ErrInsufficientOrInvalidShares, err)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("%v, set %b: %v", levels, set, err)
+			}
+			if !bytes.Equal(got, secret) {
+				t.Fatalf("%v, set %b: expected %q, got %q", levels, set, secret, got)
+			}
+		}
+	}
+}
+
+func TestCombineHierarchical_invalid(t *testing.T) {
+	d := &Dealer{Digest: true}
+	shares, err := d.SplitHierarchical([]Level{{Threshold: 1, N: 1}, {Threshold: 2, N: 2}}, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := parseHierarchical(shares[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.y[0] = scalarFromX(42)
+	tampered := s.append(nil)
+
+	// the length follows the thresholds, the level and the set ID, and is
+	// authenticated by the padding
+	longer := make([][]byte, 2)
+	for i := range longer {
+		longer[i] = bytes.Clone(shares[i+1])
+		longer[i][7+2*2+1+8+3]++
+	}
+
+	other, err := d.SplitHierarchical([]Level{{Threshold: 1, N: 1}, {Threshold: 2, N: 2}}, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		shares [][]byte
+		want   error
+	}{
+		{name: "tampered y", shares: [][]byte{shares[0], tampered}, want: ErrInsufficientOrInvalidShares},
+		{name: "altered length", shares: longer, want: ErrInsufficientOrInvalidShares},
+		{name: "inconsistent length", shares: [][]byte{shares[0], longer[1]}, want: ErrLengthMismatch},
+		{name: "different dealings", shares: [][]byte{shares[0], other[1]}, want: ErrDifferentDealing},
+		{name: "bad version", shares: [][]byte{shares[0], withByte(shares[1], 4, 1)}, want: ErrMalformedShare},
+		{name: "bad flags", shares: [][]byte{shares[0], withByte(shares[1], 5, 0x80)}, want: ErrMalformedShare},
+		{name: "truncated", shares: [][]byte{shares[0], shares[1][:len(shares[1])-1]}, want: ErrMalformedShare},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := d.CombineHierarchical(tt.shares)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestCombineHierarchical_singular(t *testing.T) {
+	shares, err := SplitHierarchical([]Level{{Threshold: 1, N: 3}, {Threshold: 3, N: 1}}, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// SplitHierarchical gives higher levels smaller x coordinates. With x = 2
+	// moved to level 1 between 1 and 3 on level 0, the rows [1 1 1], [1 3 9]
+	// and [0 1 4] are linearly dependent.
+	s, err := parseHierarchical(shares[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.level = 1
+
+	_, err = CombineHierarchical([][]byte{shares[0], shares[2], s.append(nil)})
+	if !errors.Is(err, ErrSingularMatrix) {
+		t.Fatalf("expected %v, got %v", ErrSingularMatrix, err)
+	}
+}
+
+func TestCombineHierarchical_concurrency(t *testing.T) {
+	secret := bytes.Repeat([]byte("large secret "), 1000)
+	d := &Dealer{Concurrency: 4}
+
+	shares, err := d.SplitHierarchical([]Level{{Threshold: 1, N: 2}, {Threshold: 3, N: 5}}, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := d.CombineHierarchical(shares[1:4])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, secret) {
+		t.Fatal("concurrent combine recovered a different secret")
+	}
+}
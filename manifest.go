@@ -0,0 +1,344 @@
+package shamir
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// Manifest is the public manifest of a dealing split by Dealer.SplitManifest.
+// Its root is the root of a Merkle tree over salted hashes of the shares, and
+// every share carries its inclusion proof. The manifest can be published, so
+// that holders can check that their share is part of the dealing and Combine
+// can reject shares that were swapped or altered. The salts keep the manifest
+// from revealing anything about the shares.
+type Manifest struct {
+	SetID [8]byte
+	Root  [32]byte
+}
+
+// The binary encoding of a manifest is the set ID followed by the root. If a
+// dealing has a manifest, flag bit 2 is set in the share header and the share
+// is followed by its inclusion proof
+//
+//	index uint16 | count uint16 | salt [16]byte | path [][32]byte
+//
+// where index is the position of the share among the count shares of the
+// dealing, and path holds the sibling hashes from the leaf up to the root. A
+// leaf is the SHA-256 hash of a zero byte, the salt and the encoded share
+// without its proof. An inner node is the SHA-256 hash of a one byte and its
+// children. If a level has an odd number of nodes, the last one is moved up to
+// the next level unchanged, so its path has no sibling on that level.
+const (
+	manifestSize = 8 + sha256.Size
+	saltSize     = 16
+)
+
+// inclusion proof of a share in the manifest of its dealing
+type inclusionProof struct {
+	index int
+	count int
+	salt  [saltSize]byte
+	path  [][sha256.Size]byte
+	root  [sha256.Size]byte // the root the proof leads to, not encoded
+}
+
+// SplitManifest is like Split but also returns the manifest of the dealing, and
+// every share carries its inclusion proof.
+func (d *Dealer) SplitManifest(threshold, n int, secret []byte) ([][]byte, Manifest, error) {
+	d.init()
+
+	shares, err := d.splitShares(threshold, n, nil, secret, true)
+	if err != nil {
+		return nil, Manifest{}, err
+	}
+
+	m, err := addProofs(d.Rand, shares)
+	if err != nil {
+		return nil, Manifest{}, err
+	}
+
+	byteShares := make([][]byte, len(shares))
+	for i := range shares {
+		byteShares[i], err = shares[i].MarshalBinary()
+		if err != nil {
+			return nil, Manifest{}, err
+		}
+	}
+
+	return byteShares, m, nil
+}
+
+// VerifyManifest checks that a share is part of the dealing with the given
+// manifest. If it is not, VerifyManifest returns an error matching
+// ErrManifestMismatch.
+func (d *Dealer) VerifyManifest(m Manifest, share []byte) error {
+	s, err := d.ParseShare(share)
+	if err != nil {
+		return err
+	}
+
+	return m.check(s)
+}
+
+// CombineManifest is like Combine but first checks that every share is part of
+// the dealing with the given manifest.
+func (d *Dealer) CombineManifest(m Manifest, shares [][]byte) ([]byte, error) {
+	d.init()
+
+	parsed, err := d.parseShares(shares)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, s := range parsed {
+		err := m.check(s)
+		if err != nil {
+			return nil, &ShareError{Index: i, Err: err}
+		}
+	}
+
+	return d.CombineShares(parsed)
+}
+
+// SplitManifest splits a secret with a manifest using the default dealer.
+func SplitManifest(threshold, n int, secret []byte) ([][]byte, Manifest, error) {
+	return Default.SplitManifest(threshold, n, secret)
+}
+
+// VerifyManifest checks a share against a manifest using the default dealer.
+func VerifyManifest(m Manifest, share []byte) error {
+	return Default.VerifyManifest(m, share)
+}
+
+// CombineManifest combines shares checked against a manifest using the
+// default dealer.
+func CombineManifest(m Manifest, shares [][]byte) ([]byte, error) {
+	return Default.CombineManifest(m, shares)
+}
+
+// MarshalBinary encodes the manifest as its set ID followed by its root.
+func (m Manifest) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, manifestSize)
+	b = append(b, m.SetID[:]...)
+	b = append(b, m.Root[:]...)
+
+	return b, nil
+}
+
+// UnmarshalBinary decodes a manifest encoded by MarshalBinary.
+func (m *Manifest) UnmarshalBinary(b []byte) error {
+	if len(b) != manifestSize {
+		return fmt.Errorf("%w: invalid length", ErrMalformedManifest)
+	}
+
+	m.SetID = [8]byte(b)
+	m.Root = [sha256.Size]byte(b[8:])
+	return nil
+}
+
+// MarshalText encodes the manifest as the hexadecimal form of MarshalBinary.
+func (m Manifest) MarshalText() ([]byte, error) {
+	b, err := m.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	return hex.AppendEncode(nil, b), nil
+}
+
+// UnmarshalText decodes a manifest encoded by MarshalText.
+func (m *Manifest) UnmarshalText(text []byte) error {
+	b, err := hex.AppendDecode(nil, text)
+	if err != nil {
+		return err
+	}
+
+	return m.UnmarshalBinary(b)
+}
+
+// check that a share is part of the dealing with manifest m
+func (m Manifest) check(s Share) error {
+	if s.proof == nil {
+		return fmt.Errorf("%w: share has no inclusion proof", ErrManifestMismatch)
+	}
+	if s.setID != m.SetID || s.proof.root != m.Root {
+		return ErrManifestMismatch
+	}
+
+	return nil
+}
+
+// check that the shares of a dealing with a manifest lead to the same root,
+// and report the first share that disagrees with the most common one
+func checkRoots(shares []Share) error {
+	counts := make(map[[sha256.Size]byte]int)
+	var root [sha256.Size]byte
+	for _, s := range shares {
+		if s.proof == nil {
+			return nil
+		}
+
+		counts[s.proof.root]++
+		if counts[s.proof.root] > counts[root] {
+			root = s.proof.root
+		}
+	}
+
+	for i, s := range shares {
+		if s.proof.root != root {
+			return &ShareError{Index: i, Err: ErrManifestMismatch}
+		}
+	}
+
+	return nil
+}
+
+// salt the shares of a dealing, add their inclusion proofs and return the
+// manifest
+func addProofs(random io.Reader, shares []Share) (Manifest, error) {
+	leaves := make([][sha256.Size]byte, len(shares))
+	proofs := make([]inclusionProof, len(shares))
+	for i := range shares {
+		proofs[i] = inclusionProof{index: i, count: len(shares)}
+		_, err := io.ReadFull(random, proofs[i].salt[:])
+		if err != nil {
+			return Manifest{}, err
+		}
+
+		body, err := shares[i].MarshalBinary()
+		if err != nil {
+			return Manifest{}, err
+		}
+		leaves[i] = leafHash(proofs[i].salt, body)
+	}
+
+	root, paths := merkleTree(leaves)
+	for i := range shares {
+		proofs[i].path = paths[i]
+		proofs[i].root = root
+		shares[i].proof = &proofs[i]
+	}
+
+	return Manifest{SetID: shares[0].setID, Root: root}, nil
+}
+
+func (p *inclusionProof) append(b []byte) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(p.index))
+	b = binary.BigEndian.AppendUint16(b, uint16(p.count))
+	b = append(b, p.salt[:]...)
+	for _, h := range p.path {
+		b = append(b, h[:]...)
+	}
+
+	return b
+}
+
+// decode the inclusion proof b of the share encoded as body
+func parseProof(body, b []byte) (*inclusionProof, error) {
+	if len(b) < 4+saltSize {
+		return nil, fmt.Errorf("%w: inclusion proof too short", ErrMalformedShare)
+	}
+
+	p := &inclusionProof{
+		index: int(binary.BigEndian.Uint16(b)),
+		count: int(binary.BigEndian.Uint16(b[2:])),
+		salt:  [saltSize]byte(b[4:]),
+	}
+	b = b[4+saltSize:]
+
+	if p.index >= p.count || len(b) != pathLen(p.index, p.count)*sha256.Size {
+		return nil, fmt.Errorf("%w: invalid inclusion proof", ErrMalformedShare)
+	}
+
+	p.path = make([][sha256.Size]byte, len(b)/sha256.Size)
+	for i := range p.path {
+		p.path[i] = [sha256.Size]byte(b[i*sha256.Size:])
+	}
+
+	p.root = merkleRoot(leafHash(p.salt, body), p.index, p.count, p.path)
+	return p, nil
+}
+
+func leafHash(salt [saltSize]byte, body []byte) [sha256.Size]byte {
+	h := sha256.New()
+	h.Write([]byte{0})
+	h.Write(salt[:])
+	h.Write(body)
+
+	return [sha256.Size]byte(h.Sum(nil))
+}
+
+func nodeHash(left, right [sha256.Size]byte) [sha256.Size]byte {
+	h := sha256.New()
+	h.Write([]byte{1})
+	h.Write(left[:])
+	h.Write(right[:])
+
+	return [sha256.Size]byte(h.Sum(nil))
+}
+
+// return the root of the Merkle tree over leaves and the path of every leaf
+func merkleTree(leaves [][sha256.Size]byte) ([sha256.Size]byte, [][][sha256.Size]byte) {
+	paths := make([][][sha256.Size]byte, len(leaves))
+	pos := make([]int, len(leaves)) // position of every leaf's ancestor on the current level
+	for i := range pos {
+		pos[i] = i
+	}
+
+	level := leaves
+	for len(level) > 1 {
+		for i, p := range pos {
+			if p^1 < len(level) {
+				paths[i] = append(paths[i], level[p^1])
+			}
+			pos[i] = p / 2
+		}
+
+		next := make([][sha256.Size]byte, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 < len(level) {
+				next[i/2] = nodeHash(level[i], level[i+1])
+			} else {
+				next[i/2] = level[i]
+			}
+		}
+		level = next
+	}
+
+	return level[0], paths
+}
+
+// return the root the path of the leaf at index among count leaves leads to.
+// The path must have pathLen(index, count) elements.
+func merkleRoot(leaf [sha256.Size]byte, index, count int, path [][sha256.Size]byte) [sha256.Size]byte {
+	h := leaf
+	for ; count > 1; index, count = index/2, (count+1)/2 {
+		if index^1 >= count {
+			continue
+		}
+
+		if index%2 == 0 {
+			h = nodeHash(h, path[0])
+		} else {
+			h = nodeHash(path[0], h)
+		}
+		path = path[1:]
+	}
+
+	return h
+}
+
+// number of sibling hashes in the path of the leaf at index among count leaves
+func pathLen(index, count int) int {
+	var n int
+	for ; count > 1; index, count = index/2, (count+1)/2 {
+		if index^1 < count {
+			n++
+		}
+	}
+
+	return n
+}
@@ -0,0 +1,155 @@
+package shamir
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"testing"
+)
+
+func TestSplitManifest(t *testing.T) {
+	secret := []byte("attack at dawn")
+
+	for _, n := range []int{1, 2, 3, 5, 8, 13} {
+		shares, m, err := SplitManifest(min(3, n), n, secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i, share := range shares {
+			if err := VerifyManifest(m, share); err != nil {
+				t.Fatalf("n=%d, share %d: %v", n, i, err)
+			}
+		}
+
+		got, err := CombineManifest(m, shares)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, secret) {
+			t.Fatalf("expected %q, got %q", secret, got)
+		}
+
+		got, err = Combine(shares[n-min(3, n):])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, secret) {
+			t.Fatalf("expected %q, got %q", secret, got)
+		}
+	}
+}
+
+func TestManifest_text(t *testing.T) {
+	_, m, err := SplitManifest(2, 3, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text, err := m.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got Manifest
+	if err := got.UnmarshalText(text); err != nil {
+		t.Fatal(err)
+	}
+	if got != m {
+		t.Fatalf("expected %v, got %v", m, got)
+	}
+
+	if err := got.UnmarshalText(text[:len(text)-2]); !errors.Is(err, ErrMalformedManifest) {
+		t.Fatalf("expected %v, got %v", ErrMalformedManifest, err)
+	}
+}
+
+func TestVerifyManifest(t *testing.T) {
+	shares, m, err := SplitManifest(2, 5, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := Split(2, 5, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, other, err := SplitManifest(2, 5, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the y words start after the header, the length and the x coordinate,
+	// the salt after the 8 bytes of y words and the proof index and count
+	altered := withByte(shares[1], headerSize+6, shares[1][headerSize+6]^1)
+	salt := headerSize + 6 + 8 + 4
+
+	tests := []struct {
+		name     string
+		manifest Manifest
+		share    []byte
+		want     error
+	}{
+		{name: "altered y", manifest: m, share: altered, want: ErrManifestMismatch},
+		{name: "altered salt", manifest: m, share: withByte(shares[1], salt, shares[1][salt]^1), want: ErrManifestMismatch},
+		{name: "other manifest", manifest: other, share: shares[1], want: ErrManifestMismatch},
+		{name: "no proof", manifest: m, share: plain[1], want: ErrManifestMismatch},
+		{name: "truncated proof", manifest: m, share: shares[1][:len(shares[1])-1], want: ErrMalformedShare},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyManifest(tt.manifest, tt.share)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestCombine_manifest(t *testing.T) {
+	shares, m, err := SplitManifest(2, 5, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	altered := withByte(shares[0], headerSize+6, shares[0][headerSize+6]^1)
+	tampered := [][]byte{altered, shares[1], shares[2]}
+
+	// without the manifest, the share disagreeing with the others is rejected
+	_, err = Combine(tampered)
+	var serr *ShareError
+	if !errors.As(err, &serr) || serr.Index != 0 || !errors.Is(err, ErrManifestMismatch) {
+		t.Fatalf("expected manifest mismatch for share 0, got %v", err)
+	}
+
+	_, err = CombineManifest(m, tampered)
+	if !errors.As(err, &serr) || serr.Index != 0 || !errors.Is(err, ErrManifestMismatch) {
+		t.Fatalf("expected manifest mismatch for share 0, got %v", err)
+	}
+
+	a := NewAccumulator(0)
+	if err := a.Add(shares[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Add(altered); !errors.Is(err, ErrManifestMismatch) {
+		t.Fatalf("expected %v, got %v", ErrManifestMismatch, err)
+	}
+}
+
+func Test_merkleTree(t *testing.T) {
+	for count := 1; count <= 17; count++ {
+		leaves := make([][sha256.Size]byte, count)
+		for i := range leaves {
+			leaves[i][0] = byte(i)
+		}
+
+		root, paths := merkleTree(leaves)
+		for i := range leaves {
+			if len(paths[i]) != pathLen(i, count) {
+				t.Fatalf("count %d, leaf %d: expected path length %d, got %d", count, i, pathLen(i, count), len(paths[i]))
+			}
+			if got := merkleRoot(leaves[i], i, count, paths[i]); got != root {
+				t.Fatalf("count %d, leaf %d: path does not lead to the root", count, i)
+			}
+		}
+	}
+}
@@ -6,46 +6,75 @@ import (
 	"github.com/wbrc/gf65536"
 )
 
-func gauss(f gf65536.Field, m [][]uint16) error {
-	// upper triangular form
-	for r := 0; r < len(m); r++ {
-		if m[r][r] == 0 {
-			i := findNonzero(m, r)
-			if i == -1 {
-				return errors.New("matrix is singular")
-			}
-			m[r], m[i] = m[i], m[r]
+// solve the linear system given by the augmented matrix m and return a
+// solution, where free variables are set to 0
+func solve(f gf65536.Field, m [][]uint16) ([]uint16, error) {
+	return solveIn(gf16{f}, m)
+}
+
+// solve for any field
+func solveIn[E any, F field[E]](f F, m [][]E) ([]E, error) {
+	cols := len(m[0]) - 1
+	pivots := rrefIn(f, m)
+
+	for r := len(pivots); r < len(m); r++ {
+		if !f.IsZero(m[r][cols]) {
+			return nil, errors.New("system is inconsistent")
 		}
+	}
 
-		for i := r; i < len(m); i++ {
-			if m[i][r] == 0 {
-				continue
-			}
-			scalePoly(f, m[i], m[i], f.Inv(m[i][r]))
+	v := make([]E, cols)
+	for c := range v {
+		v[c] = f.Zero()
+	}
+	for r, c := range pivots {
+		v[c] = m[r][cols]
+	}
+
+	return v, nil
+}
+
+// bring the augmented matrix m to reduced row echelon form and return the
+// pivot column of every nonzero row
+func rrefIn[E any, F field[E]](f F, m [][]E) []int {
+	cols := len(m[0]) - 1
+	pivots := make([]int, 0, min(len(m), cols))
+
+	for c := 0; c < cols && len(pivots) < len(m); c++ {
+		r := len(pivots)
+		i := findNonzeroColIn(f, m, r, c)
+		if i == -1 {
+			continue
+		}
+		m[r], m[i] = m[i], m[r]
+
+		// the entries of row r left of column c are zero
+		inv := f.Inv(m[r][c])
+		for j := c; j <= cols; j++ {
+			m[r][j] = f.Mul(m[r][j], inv)
 		}
 
-		for i := r + 1; i < len(m); i++ {
-			if m[i][r] == 0 {
+		for i := range m {
+			if i == r || f.IsZero(m[i][c]) {
 				continue
 			}
-			addPoly(f, m[i], m[i], m[r])
+			factor := m[i][c]
+			for j := c; j <= cols; j++ {
+				m[i][j] = f.Sub(m[i][j], f.Mul(factor, m[r][j]))
+			}
 		}
-	}
 
-	// back substitute to have row[0] = [1, 0, 0, ..., secret]
-	for r := 1; r < len(m); r++ {
-		scalePoly(f, m[r], m[r], m[0][r])
-		addPoly(f, m[0], m[0], m[r])
+		pivots = append(pivots, c)
 	}
 
-	return nil
+	return pivots
 }
 
-// return index of first row in m[r:] where the element at column r is nonzero
+// return index of first row in m[r:] where the element at column c is nonzero
 // or -1 otherwise
-func findNonzero(m [][]uint16, r int) int {
+func findNonzeroColIn[E any, F field[E]](f F, m [][]E, r, c int) int {
 	for i := r; i < len(m); i++ {
-		if m[i][r] != 0 {
+		if !f.IsZero(m[i][c]) {
 			return i
 		}
 	}
@@ -62,8 +91,66 @@ func pows(f gf65536.Field, v []uint16, x uint16) {
 	}
 }
 
+// set w to the Lagrange basis polynomials for xvals evaluated at x, such that
+// p(x) = w[0]*p(xvals[0]) + w[1]*p(xvals[1]) + ... for every polynomial p of
+// degree less than len(xvals)
+func lagrange(f gf65536.Field, w, xvals []uint16, x uint16) error {
+	return lagrangeIn(gf16{f}, w, xvals, x)
+}
+
+// lagrange for any field
+func lagrangeIn[E any, F field[E]](f F, w, xvals []E, x E) error {
+	// w[i] = (x - x0) * ... * (x - x(i-1)) * (x - x(i+1)) * ... * (x - xn)
+	p := f.One()
+	for i := range xvals {
+		w[i] = p
+		p = f.Mul(p, f.Sub(x, xvals[i]))
+	}
+	p = f.One()
+	for i := len(xvals) - 1; i >= 0; i-- {
+		w[i] = f.Mul(w[i], p)
+		p = f.Mul(p, f.Sub(x, xvals[i]))
+	}
+
+	// w[i] /= (xi - x0) * ... * (xi - x(i-1)) * (xi - x(i+1)) * ... * (xi - xn)
+	for i := range xvals {
+		d := f.One()
+		for j := range xvals {
+			if j != i {
+				d = f.Mul(d, f.Sub(xvals[i], xvals[j]))
+			}
+		}
+		if f.IsZero(d) {
+			return ErrDuplicateShare
+		}
+		w[i] = f.Mul(w[i], f.Inv(d))
+	}
+
+	return nil
+}
+
+// return a[0]*b[0] + a[1]*b[1] + ...
+func dot(f gf65536.Field, a, b []uint16) uint16 {
+	return dotIn(gf16{f}, a, b)
+}
+
+// dot for any field
+func dotIn[E any, F field[E]](f F, a, b []E) E {
+	r := f.Zero()
+	for i := range a {
+		r = f.Add(r, f.Mul(a[i], b[i]))
+	}
+
+	return r
+}
+
 func evalPoly(f gf65536.Field, coeff []uint16, x uint16) uint16 {
-	var p, r uint16 = 1, 0
+	return evalPolyIn(gf16{f}, coeff, x)
+}
+
+// evalPoly for any field
+func evalPolyIn[E any, F field[E]](f F, coeff []E, x E) E {
+	p, r := f.One(), f.Zero()
 	for i := 0; i < len(coeff); i++ {
 		r = f.Add(r, f.Mul(p, coeff[i]))
 		p = f.Mul(p, x)
@@ -83,3 +170,24 @@ func addPoly(f gf65536.Field, z, a, b []uint16) {
 		z[i] = f.Add(a[i], b[i])
 	}
 }
+
+// divide the polynomial num by den and return quotient and remainder; den must
+// have a nonzero leading coefficient
+func divPoly(f gf65536.Field, num, den []uint16) ([]uint16, []uint16) {
+	if len(num) < len(den) {
+		return nil, append([]uint16(nil), num...)
+	}
+
+	r := append([]uint16(nil), num...)
+	q := make([]uint16, len(num)-len(den)+1)
+	inv := f.Inv(den[len(den)-1])
+
+	for i := len(q) - 1; i >= 0; i-- {
+		q[i] = f.Mul(r[i+len(den)-1], inv)
+		for j := range den {
+			r[i+j] = f.Add(r[i+j], f.Mul(q[i], den[j]))
+		}
+	}
+
+	return q, r[:len(den)-1]
+}
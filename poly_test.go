@@ -9,45 +9,11 @@ import (
 
 var f = gf65536.Default
 
-func Test_gauss(t *testing.T) {
-	poly := []uint16{5890, 301, 30222, 12345} // poly[0] is the secret
-	xvals := []uint16{10, 55, 16, 1111}       // 4 samples needed for interpolation
-
-	// x0^0 x0^1 x0^2 x0^3 | y0
-	// x1^0 x1^1 x1^2 x1^3 | y1
-	// x2^0 x2^1 x2^2 x2^3 | y2
-	// x3^0 x3^1 x3^2 x3^3 | y3
-	m := make([][]uint16, len(poly))
-	for i := range m {
-		m[i] = make([]uint16, len(poly)+1)
-		pows(f, m[i], xvals[i])
-		m[i][len(poly)] = evalPoly(f, poly, xvals[i])
-	}
-
-	if err := gauss(f, m); err != nil {
-		t.Error(err)
-	}
-
-	if m[0][len(m[0])-1] != poly[0] {
-		t.Error("gauss failed")
-	}
-}
-
-func Test_gauss_unsolvable(t *testing.T) {
-	err := gauss(f, [][]uint16{
-		{0, 1, 1, 9},
-		{2, 4, 7, 8},
-		{0, 0, 0, 7},
-	})
-	if err == nil {
-		t.Error("expected error")
-	}
-}
-
-func Test_findNonzero(t *testing.T) {
+func Test_findNonzeroCol(t *testing.T) {
 	type args struct {
 		m [][]uint16
 		r int
+		c int
 	}
 	tests := []struct {
 		name string
@@ -60,7 +26,7 @@ func Test_findNonzero(t *testing.T) {
 				{0, 1, 1},
 				{1, 1, 1},
 				{2, 1, 1},
-			}, 0}, 1,
+			}, 0, 0}, 1,
 		},
 		{
 			"ok",
@@ -68,7 +34,7 @@ func Test_findNonzero(t *testing.T) {
 				{0, 1, 1},
 				{0, 1, 1},
 				{2, 1, 1},
-			}, 0}, 2,
+			}, 0, 0}, 2,
 		},
 		{
 			"nok",
@@ -76,13 +42,13 @@ func Test_findNonzero(t *testing.T) {
 				{1, 0, 1},
 				{1, 0, 1},
 				{2, 0, 1},
-			}, 1}, -1,
+			}, 1, 1}, -1,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := findNonzero(tt.args.m, tt.args.r); got != tt.want {
-				t.Errorf("findNonzero() = %v, want %v", got, tt.want)
+			if got := findNonzeroColIn(gf16{f}, tt.args.m, tt.args.r, tt.args.c); got != tt.want {
+				t.Errorf("findNonzeroCol() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -196,3 +162,74 @@ func Test_addPoly(t *testing.T) {
 		t.Error("addPoly failed")
 	}
 }
+
+func Test_lagrange(t *testing.T) {
+	poly := []uint16{5890, 301, 30222, 12345}
+	xvals := []uint16{10, 55, 16, 1111}
+	yvals := make([]uint16, len(xvals))
+	for i, x := range xvals {
+		yvals[i] = evalPoly(f, poly, x)
+	}
+
+	w := make([]uint16, len(xvals))
+	for _, x := range []uint16{0, 1, 10, 4242} {
+		if err := lagrange(f, w, xvals, x); err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := dot(f, w, yvals), evalPoly(f, poly, x); got != want {
+			t.Errorf("lagrange() at %d = %v, want %v", x, got, want)
+		}
+	}
+
+	if err := lagrange(f, w, []uint16{10, 55, 10, 1111}, 0); err == nil {
+		t.Error("expected error for duplicate x coordinates")
+	}
+}
+
+func Test_solve(t *testing.T) {
+	// overdetermined but consistent
+	poly := []uint16{5890, 301, 30222}
+	xvals := []uint16{10, 55, 16, 1111}
+	m := make([][]uint16, len(xvals))
+	for i := range m {
+		m[i] = make([]uint16, len(poly)+1)
+		pows(f, m[i][:len(poly)], xvals[i])
+		m[i][len(poly)] = evalPoly(f, poly, xvals[i])
+	}
+
+	got, err := solve(f, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, poly) {
+		t.Errorf("solve() = %v, want %v", got, poly)
+	}
+
+	_, err = solve(f, [][]uint16{
+		{1, 1, 2},
+		{1, 1, 3},
+	})
+	if err == nil {
+		t.Error("expected error for inconsistent system")
+	}
+}
+
+func Test_divPoly(t *testing.T) {
+	a := []uint16{5890, 301, 30222}
+	b := []uint16{7, 1}
+
+	// a*b + r
+	num := make([]uint16, len(a)+len(b)-1)
+	for i := range a {
+		for j := range b {
+			num[i+j] = f.Add(num[i+j], f.Mul(a[i], b[j]))
+		}
+	}
+	num[0] = f.Add(num[0], 42)
+
+	q, r := divPoly(f, num, b)
+	if !reflect.DeepEqual(q, a) || !reflect.DeepEqual(r, []uint16{42}) {
+		t.Errorf("divPoly() = %v, %v, want %v, %v", q, r, a, []uint16{42})
+	}
+}
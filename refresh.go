@@ -0,0 +1,273 @@
+package shamir
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+
+	"github.com/wbrc/gf65536"
+)
+
+// Refresh rotates shares without changing the secret. It adds the shares of a
+// random polynomial with a zero constant term and degree threshold-1 to the
+// given shares, keeping their x coordinates. The refreshed shares recover the
+// same secret, but they can not be combined with shares from before the
+// refresh, so every share that should stay valid must be refreshed at once.
+// If the shares record the threshold, a threshold of 0 means to use it.
+//
+// The refreshed shares get a new set ID, unless the dealing has a digest,
+// which is keyed with the set ID; then combining old and new shares fails
+// with ErrInsufficientOrInvalidShares instead. Refreshed shares do not carry
+// inclusion proofs, since they are not part of the manifest of the dealing.
+//
+// Refresh sees all shares, so it must run on a trusted dealer. Holders that
+// do not trust a dealer can refresh their shares together with ZeroSharing
+// and ApplyRefresh instead.
+func (d *Dealer) Refresh(threshold int, shares [][]byte) ([][]byte, error) {
+	d.init()
+
+	parsed, err := d.parseShares(shares)
+	if err != nil {
+		return nil, err
+	}
+	for i := range parsed {
+		parsed[i] = parsed[i].withPadding()
+	}
+
+	h, wordShares, err := checkShares(parsed)
+	if err != nil {
+		return nil, err
+	}
+
+	h.threshold, err = refreshThreshold(h, threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	xvals := make([]uint16, len(wordShares))
+	for i := range wordShares {
+		xvals[i] = wordShares[i][0]
+	}
+
+	zeros, err := zeroShares(h.field, d.Rand, h.threshold, xvals, len(wordShares[0])-1, d.Concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	h.manifest = false
+	if !h.digest {
+		_, err = io.ReadFull(d.Rand, h.setID[:])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	refreshed := make([][]byte, len(parsed))
+	for i, s := range parsed {
+		refreshed[i], err = addShare(h, s, zeros[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return refreshed, nil
+}
+
+// ZeroSharing returns the contribution of a holder to a distributed refresh.
+// Every holder of the shares to refresh calls it with their own share and the
+// x coordinates xs of all holders. It returns shares of zero, in the same
+// format as share, and the i-th share must be sent to the holder with x
+// coordinate xs[i]. Every holder then passes the shares of zero they received
+// from all holders to ApplyRefresh. If the share records the threshold, a
+// threshold of 0 means to use it.
+func (d *Dealer) ZeroSharing(threshold int, share []byte, xs []uint16) ([][]byte, error) {
+	d.init()
+
+	s, err := d.ParseShare(share)
+	if err != nil {
+		return nil, err
+	}
+	s = s.withPadding()
+	if len(xs) == 0 {
+		return nil, ErrNoShares
+	}
+
+	err = checkXes(xs)
+	if err != nil {
+		return nil, err
+	}
+
+	// the set ID of a contribution is a random nonce, from which the new set
+	// ID is derived
+	h := header{field: s.field, byteOrder: s.byteOrder}
+	h.threshold, err = refreshThreshold(s.header, threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = io.ReadFull(d.Rand, h.setID[:])
+	if err != nil {
+		return nil, err
+	}
+
+	zeros, err := zeroShares(h.field, d.Rand, h.threshold, xs, len(s.words)-1, d.Concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	// a contribution does not share a padded secret, its length only has to
+	// match the number of words
+	contribution := make([][]byte, len(xs))
+	for i, x := range xs {
+		z := Share{
+			header: h,
+			words:  append([]uint16{x}, zeros[i]...),
+			length: len(zeros[i])*2 - 1,
+		}
+
+		contribution[i], err = z.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return contribution, nil
+}
+
+// ApplyRefresh completes a distributed refresh for one holder. It adds the
+// shares of zero the holder received from all holders, as returned by
+// ZeroSharing, to the holder's share. The shares of zero must be for the x
+// coordinate of the share and from distinct contributions. The refreshed
+// share gets a set ID derived from the contributions, so holders that applied
+// different contributions end up with shares from different dealings. As with
+// Refresh, dealings with a digest keep their set ID.
+func (d *Dealer) ApplyRefresh(share []byte, zeroShares [][]byte) ([]byte, error) {
+	d.init()
+
+	s, err := d.ParseShare(share)
+	if err != nil {
+		return nil, err
+	}
+	s = s.withPadding()
+
+	zs, err := d.parseShares(zeroShares)
+	if err != nil {
+		return nil, err
+	}
+
+	nonces := make(map[[8]byte]int, len(zs))
+	for i, z := range zs {
+		if z.X() != s.X() {
+			return nil, &ShareError{Index: i, Err: fmt.Errorf("%w: share of zero is for x coordinate %d, not %d", ErrParamsMismatch, z.X(), s.X())}
+		}
+		if z.field != s.field || z.byteOrder != s.byteOrder || z.digest || z.manifest || z.threshold != zs[0].threshold {
+			return nil, &ShareError{Index: i, Err: ErrParamsMismatch}
+		}
+		if len(z.words) != len(s.words) {
+			return nil, &ShareError{Index: i, Err: ErrLengthMismatch}
+		}
+		if j, ok := nonces[z.setID]; ok {
+			return nil, &DuplicateShareError{I: j, J: i, X: z.X()}
+		}
+		nonces[z.setID] = i
+	}
+
+	h := s.header
+	h.threshold, err = refreshThreshold(h, zs[0].threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	h.manifest = false
+	if !h.digest {
+		ids := make([][8]byte, 0, len(zs))
+		for id := range nonces {
+			ids = append(ids, id)
+		}
+		slices.SortFunc(ids, func(a, b [8]byte) int {
+			return bytes.Compare(a[:], b[:])
+		})
+
+		hash := sha256.New()
+		hash.Write([]byte("shamir refresh"))
+		hash.Write(s.setID[:])
+		for _, id := range ids {
+			hash.Write(id[:])
+		}
+		h.setID = [8]byte(hash.Sum(nil))
+	}
+
+	y := make([]uint16, len(s.words)-1)
+	for _, z := range zs {
+		for c := range y {
+			y[c] = h.field.Add(y[c], z.words[c+1])
+		}
+	}
+
+	return addShare(h, s, y)
+}
+
+// Refresh refreshes shares using the default dealer.
+func Refresh(threshold int, shares [][]byte) ([][]byte, error) {
+	return Default.Refresh(threshold, shares)
+}
+
+// ZeroSharing creates a contribution to a distributed refresh using the
+// default dealer.
+func ZeroSharing(threshold int, share []byte, xs []uint16) ([][]byte, error) {
+	return Default.ZeroSharing(threshold, share, xs)
+}
+
+// ApplyRefresh applies the contributions to a distributed refresh using the
+// default dealer.
+func ApplyRefresh(share []byte, zeroShares [][]byte) ([]byte, error) {
+	return Default.ApplyRefresh(share, zeroShares)
+}
+
+// return the threshold of a refresh, given the header of the shares and the
+// threshold passed by the caller, 0 meaning the recorded one
+func refreshThreshold(h header, threshold int) (int, error) {
+	if threshold == 0 {
+		threshold = h.threshold
+	}
+	if h.threshold != 0 && threshold != h.threshold {
+		return 0, fmt.Errorf("%w: shares record %d", ErrThresholdMismatch, h.threshold)
+	}
+	if threshold < 1 {
+		return 0, ErrThresholdTooSmall
+	}
+	if threshold > math.MaxUint16 {
+		return 0, ErrThresholdTooLarge
+	}
+
+	return threshold, nil
+}
+
+// return the y words of shares of words zeros at xvals
+func zeroShares(f gf65536.Field, random io.Reader, threshold int, xvals []uint16, words, workers int) ([][]uint16, error) {
+	ys := make([][]uint16, len(xvals))
+	for i := range ys {
+		ys[i] = make([]uint16, words)
+	}
+
+	err := splitWords(f, random, threshold, xvals, make([]uint16, words), ys, workers)
+	if err != nil {
+		return nil, err
+	}
+
+	return ys, nil
+}
+
+// encode share s with the y words y added to its own and header h
+func addShare(h header, s Share, y []uint16) ([]byte, error) {
+	words := make([]uint16, len(s.words))
+	words[0] = s.words[0]
+	for c := range y {
+		words[c+1] = h.field.Add(s.words[c+1], y[c])
+	}
+
+	return Share{header: h, words: words, length: s.length}.MarshalBinary()
+}
@@ -0,0 +1,186 @@
+package shamir
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func TestRefresh(t *testing.T) {
+	secret := []byte("quarterly rotation")
+
+	for _, digest := range []bool{false, true} {
+		d := Dealer{Digest: digest}
+
+		shares, err := d.Split(3, 5, secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		refreshed, err := d.Refresh(0, shares)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := range shares {
+			if bytes.Equal(shares[i], refreshed[i]) {
+				t.Fatalf("share %d was not refreshed", i)
+			}
+		}
+
+		got, err := d.Combine(refreshed[2:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, secret) {
+			t.Fatalf("expected %q, got %q", secret, got)
+		}
+
+		// old and new shares do not mix
+		want := ErrDifferentDealing
+		if digest {
+			want = ErrInsufficientOrInvalidShares
+		}
+		_, err = d.Combine([][]byte{refreshed[0], refreshed[1], shares[2]})
+		if !errors.Is(err, want) {
+			t.Fatalf("expected %v, got %v", want, err)
+		}
+	}
+}
+
+func TestRefresh_legacy(t *testing.T) {
+	var shares [][]byte
+	for _, s := range []string{
+		"2b5fa84e897199d026b9b469fee4090f",
+		"a9c313cbbd97c90024791b249488d987",
+		"bbf4de08656d1ed177f85ecb7b9c9fb1",
+		"e38d56eb3ae280910595df9515ca7e2d",
+	} {
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		shares = append(shares, b)
+	}
+
+	if _, err := Refresh(0, shares); !errors.Is(err, ErrThresholdTooSmall) {
+		t.Fatalf("expected %v, got %v", ErrThresholdTooSmall, err)
+	}
+
+	refreshed, err := Refresh(4, shares)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Combine(refreshed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello, world!!" {
+		t.Fatalf("expected %q, got %q", "hello, world!!", got)
+	}
+}
+
+func TestApplyRefresh(t *testing.T) {
+	secret := []byte("distributed rotation")
+
+	shares, err := Split(3, 4, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	xs := make([]uint16, len(shares))
+	for i := range shares {
+		s, err := Default.ParseShare(shares[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		xs[i] = s.X()
+	}
+
+	// received[j] holds the shares of zero sent to holder j
+	received := make([][][]byte, len(shares))
+	for _, share := range shares {
+		contribution, err := ZeroSharing(0, share, xs)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for j := range contribution {
+			received[j] = append(received[j], contribution[j])
+		}
+	}
+
+	refreshed := make([][]byte, len(shares))
+	for j := range shares {
+		refreshed[j], err = ApplyRefresh(shares[j], received[j])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := Combine(refreshed[:3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, secret) {
+		t.Fatalf("expected %q, got %q", secret, got)
+	}
+
+	if _, err := Combine([][]byte{refreshed[0], refreshed[1], shares[2]}); !errors.Is(err, ErrDifferentDealing) {
+		t.Fatalf("expected %v, got %v", ErrDifferentDealing, err)
+	}
+
+	// a holder that missed a contribution ends up in a different dealing
+	partial, err := ApplyRefresh(shares[3], received[3][1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Combine([][]byte{refreshed[0], refreshed[1], partial}); !errors.Is(err, ErrDifferentDealing) {
+		t.Fatalf("expected %v, got %v", ErrDifferentDealing, err)
+	}
+
+	tests := []struct {
+		name  string
+		share []byte
+		zeros [][]byte
+		want  error
+	}{
+		{name: "nil shares of zero", share: shares[0], want: ErrNoShares},
+		{name: "wrong x", share: shares[0], zeros: received[1], want: ErrParamsMismatch},
+		{name: "duplicate contribution", share: shares[0], zeros: [][]byte{received[0][0], received[0][1], received[0][0]}, want: ErrDuplicateShare},
+		{name: "threshold mismatch", share: shares[0], zeros: zeroSharing(t, 2, shares[0], xs[:1]), want: ErrThresholdMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ApplyRefresh(tt.share, tt.zeros)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// create a zero sharing for a threshold, from a dealing of a secret as long as
+// the one of share
+func zeroSharing(t *testing.T, threshold int, share []byte, xs []uint16) [][]byte {
+	t.Helper()
+
+	s, err := Default.ParseShare(share)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := Split(threshold, threshold, make([]byte, s.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zeros, err := ZeroSharing(0, other[0], xs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return zeros
+}
@@ -0,0 +1,218 @@
+package shamir
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"slices"
+)
+
+// The binary encoding of a sub-share sent from an old holder to a new holder
+// during resharing is
+//
+//	n uint16 | old x [n]uint16 | from uint16 | share
+//
+// where old x are the x coordinates of the participating old holders, from is
+// the x coordinate of the sender, and share is the encoded share of the
+// sender's share for the new holder, in the new dealing. All integers are
+// big-endian.
+
+// Reshare is the first step of resharing a secret to a new threshold and a new
+// set of holders, without recovering it in one place. Every participating old
+// holder calls Reshare with their share, the x coordinates oldXs of the
+// participating old holders, of which there must be at least the old
+// threshold, and the x coordinates newXs of the new holders. All old holders
+// must pass the same coordinates in the same order. Reshare splits the
+// share with the new threshold and returns one sub-share per new holder, the
+// i-th for the holder with x coordinate newXs[i]. Every new holder then passes
+// the sub-shares they received from all old holders to CombineReshares.
+//
+// If the old shares do not record the threshold, it is up to the caller to
+// make sure that enough old holders participate. As with Refresh, the new
+// dealing gets a new set ID unless it has a digest, and the new shares do not
+// carry inclusion proofs.
+func (d *Dealer) Reshare(threshold int, share []byte, oldXs, newXs []uint16) ([][]byte, error) {
+	d.init()
+
+	s, err := d.ParseShare(share)
+	if err != nil {
+		return nil, err
+	}
+	s = s.withPadding()
+
+	err = checkParams(threshold, len(newXs))
+	if err != nil {
+		return nil, err
+	}
+	err = checkXes(newXs)
+	if err != nil {
+		return nil, err
+	}
+	err = checkXes(oldXs)
+	if err != nil {
+		return nil, err
+	}
+	if len(oldXs) < s.threshold {
+		return nil, fmt.Errorf("%w: have %d old holders, need %d", ErrInsufficientOrInvalidShares, len(oldXs), s.threshold)
+	}
+	if !slices.Contains(oldXs, s.X()) {
+		return nil, fmt.Errorf("%w: x coordinate %d of the share is not among the old holders", ErrParamsMismatch, s.X())
+	}
+
+	h := s.header
+	h.threshold = threshold
+	h.manifest = false
+	if !h.digest {
+		h.setID = reshareID(s.setID, threshold, oldXs, newXs)
+	}
+
+	ys := make([][]uint16, len(newXs))
+	for i := range ys {
+		ys[i] = make([]uint16, len(s.words)-1)
+	}
+
+	err = splitWords(h.field, d.Rand, threshold, newXs, s.words[1:], ys, d.Concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	subShares := make([][]byte, len(newXs))
+	for i, x := range newXs {
+		b := binary.BigEndian.AppendUint16(nil, uint16(len(oldXs)))
+		for _, oldX := range oldXs {
+			b = binary.BigEndian.AppendUint16(b, oldX)
+		}
+		b = binary.BigEndian.AppendUint16(b, s.X())
+
+		sub := Share{
+			header: h,
+			words:  append([]uint16{x}, ys[i]...),
+			length: s.length,
+		}
+		encoded, err := sub.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+
+		subShares[i] = append(b, encoded...)
+	}
+
+	return subShares, nil
+}
+
+// CombineReshares is the second step of resharing. A new holder passes the
+// sub-shares they received from all participating old holders and gets their
+// share of the new dealing. The sub-shares are combined with the Lagrange
+// weights of the old holders, so the new shares recover the same secret.
+func (d *Dealer) CombineReshares(subShares [][]byte) ([]byte, error) {
+	d.init()
+
+	if len(subShares) == 0 {
+		return nil, ErrNoShares
+	}
+
+	var oldXs []uint16
+	subs := make([]Share, len(subShares))
+	words := make([][]uint16, len(subShares)) // y words of the sub-shares at the x coordinates of their senders
+	senders := make(map[uint16]int, len(subShares))
+	for i, b := range subShares {
+		xs, from, sub, err := d.parseSubShare(b)
+		if err != nil {
+			return nil, &ShareError{Index: i, Err: err}
+		}
+
+		if i == 0 {
+			oldXs = xs
+		}
+		if !slices.Equal(xs, oldXs) {
+			return nil, &ShareError{Index: i, Err: fmt.Errorf("%w: different old holders", ErrParamsMismatch)}
+		}
+		if i > 0 && sub.setID != subs[0].setID {
+			return nil, &ShareError{Index: i, Err: ErrDifferentDealing}
+		}
+		if i > 0 && sub.header != subs[0].header {
+			return nil, &ShareError{Index: i, Err: ErrParamsMismatch}
+		}
+		if i > 0 && (sub.X() != subs[0].X() || sub.length != subs[0].length) {
+			return nil, &ShareError{Index: i, Err: fmt.Errorf("%w: sub-share is for a different holder", ErrParamsMismatch)}
+		}
+		if j, ok := senders[from]; ok {
+			return nil, &DuplicateShareError{I: j, J: i, X: from}
+		}
+		senders[from] = i
+
+		subs[i] = sub
+		words[i] = append([]uint16{from}, sub.words[1:]...)
+	}
+
+	if len(subShares) != len(oldXs) {
+		return nil, fmt.Errorf("%w: have sub-shares from %d old holders, need %d", ErrInsufficientOrInvalidShares, len(subShares), len(oldXs))
+	}
+
+	ys, err := combine(subs[0].field, words, d.Concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	s := Share{
+		header: subs[0].header,
+		words:  append([]uint16{subs[0].X()}, ys...),
+		length: subs[0].length,
+	}
+
+	return s.MarshalBinary()
+}
+
+// Reshare creates the sub-shares of an old holder using the default dealer.
+func Reshare(threshold int, share []byte, oldXs, newXs []uint16) ([][]byte, error) {
+	return Default.Reshare(threshold, share, oldXs, newXs)
+}
+
+// CombineReshares combines the sub-shares of a new holder using the default
+// dealer.
+func CombineReshares(subShares [][]byte) ([]byte, error) {
+	return Default.CombineReshares(subShares)
+}
+
+func (d *Dealer) parseSubShare(b []byte) (oldXs []uint16, from uint16, s Share, err error) {
+	if len(b) < 2 {
+		return nil, 0, Share{}, fmt.Errorf("%w: sub-share too short", ErrMalformedShare)
+	}
+
+	n := int(binary.BigEndian.Uint16(b))
+	if n == 0 || len(b) < 2+2*n+2 {
+		return nil, 0, Share{}, fmt.Errorf("%w: sub-share too short", ErrMalformedShare)
+	}
+
+	oldXs = make([]uint16, n)
+	for i := range oldXs {
+		oldXs[i] = binary.BigEndian.Uint16(b[2+2*i:])
+	}
+	from = binary.BigEndian.Uint16(b[2+2*n:])
+	if !slices.Contains(oldXs, from) {
+		return nil, 0, Share{}, fmt.Errorf("%w: sender is not among the old holders", ErrMalformedShare)
+	}
+
+	s, err = d.ParseShare(b[2+2*n+2:])
+	if err != nil {
+		return nil, 0, Share{}, err
+	}
+	if s.threshold == 0 {
+		return nil, 0, Share{}, fmt.Errorf("%w: sub-share does not record the threshold", ErrMalformedShare)
+	}
+
+	return oldXs, from, s, nil
+}
+
+// derive the set ID of a reshared dealing, so that all old holders agree on it
+func reshareID(setID [8]byte, threshold int, oldXs, newXs []uint16) [8]byte {
+	h := sha256.New()
+	h.Write([]byte("shamir reshare"))
+	h.Write(setID[:])
+	binary.Write(h, binary.BigEndian, uint16(threshold))
+	binary.Write(h, binary.BigEndian, uint16(len(oldXs)))
+	binary.Write(h, binary.BigEndian, oldXs)
+	binary.Write(h, binary.BigEndian, newXs)
+
+	return [8]byte(h.Sum(nil))
+}
@@ -0,0 +1,162 @@
+package shamir
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// reshare shares with x coordinates oldXs to new holders with x coordinates
+// newXs and return the sub-shares received by every new holder
+func reshare(t *testing.T, d *Dealer, threshold int, shares [][]byte, oldXs, newXs []uint16) [][][]byte {
+	t.Helper()
+
+	received := make([][][]byte, len(newXs))
+	for _, share := range shares {
+		subShares, err := d.Reshare(threshold, share, oldXs, newXs)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for j := range subShares {
+			received[j] = append(received[j], subShares[j])
+		}
+	}
+
+	return received
+}
+
+func TestReshare(t *testing.T) {
+	secret := []byte("committee rotation")
+	newXs := []uint16{1, 2, 3, 4, 5, 6, 7, 8, 9}
+
+	for _, digest := range []bool{false, true} {
+		d := &Dealer{Digest: digest}
+
+		shares, err := d.Split(3, 5, secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// any 3 of the old holders take part
+		participants := [][]byte{shares[4], shares[0], shares[2]}
+		oldXs := make([]uint16, len(participants))
+		for i, share := range participants {
+			s, err := d.ParseShare(share)
+			if err != nil {
+				t.Fatal(err)
+			}
+			oldXs[i] = s.X()
+		}
+
+		received := reshare(t, d, 4, participants, oldXs, newXs)
+
+		newShares := make([][]byte, len(newXs))
+		for j := range newShares {
+			newShares[j], err = d.CombineReshares(received[j])
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		got, err := d.Combine(newShares[5:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, secret) {
+			t.Fatalf("expected %q, got %q", secret, got)
+		}
+
+		if _, err := d.Combine(newShares[:3]); !errors.Is(err, ErrInsufficientOrInvalidShares) {
+			t.Fatalf("expected %v, got %v", ErrInsufficientOrInvalidShares, err)
+		}
+
+		// dealings with a digest keep their set ID, but not their threshold
+		want := ErrDifferentDealing
+		if digest {
+			want = ErrParamsMismatch
+		}
+		if _, err := d.Combine([][]byte{newShares[0], newShares[1], newShares[2], shares[1]}); !errors.Is(err, want) {
+			t.Fatalf("expected %v, got %v", want, err)
+		}
+	}
+}
+
+func TestReshare_invalid(t *testing.T) {
+	shares, err := Split(2, 3, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	xs := make([]uint16, len(shares))
+	for i := range shares {
+		s, err := Default.ParseShare(shares[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		xs[i] = s.X()
+	}
+
+	tests := []struct {
+		name  string
+		t     int
+		share []byte
+		oldXs []uint16
+		newXs []uint16
+		want  error
+	}{
+		{name: "too few old holders", t: 2, share: shares[0], oldXs: xs[:1], newXs: []uint16{1, 2}, want: ErrInsufficientOrInvalidShares},
+		{name: "not an old holder", t: 2, share: shares[2], oldXs: xs[:2], newXs: []uint16{1, 2}, want: ErrParamsMismatch},
+		{name: "threshold too large", t: 3, share: shares[0], oldXs: xs, newXs: []uint16{1, 2}, want: ErrThresholdTooLarge},
+		{name: "duplicate new x", t: 2, share: shares[0], oldXs: xs, newXs: []uint16{1, 1}, want: ErrDuplicateShare},
+		{name: "zero new x", t: 2, share: shares[0], oldXs: xs, newXs: []uint16{0, 1}, want: ErrZeroX},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Reshare(tt.t, tt.share, tt.oldXs, tt.newXs)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestCombineReshares_invalid(t *testing.T) {
+	shares, err := Split(2, 3, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	xs := make([]uint16, len(shares))
+	for i := range shares {
+		s, err := Default.ParseShare(shares[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		xs[i] = s.X()
+	}
+
+	received := reshare(t, Default, 2, shares[:2], xs[:2], []uint16{1, 2})
+	other := reshare(t, Default, 2, shares[1:], xs[1:], []uint16{1, 2})
+
+	tests := []struct {
+		name      string
+		subShares [][]byte
+		want      error
+	}{
+		{name: "missing sub-share", subShares: received[0][:1], want: ErrInsufficientOrInvalidShares},
+		{name: "duplicate sender", subShares: [][]byte{received[0][0], received[0][0]}, want: ErrDuplicateShare},
+		{name: "different holders", subShares: [][]byte{received[0][0], received[1][1]}, want: ErrParamsMismatch},
+		{name: "different old holders", subShares: [][]byte{received[0][1], other[0][0]}, want: ErrParamsMismatch},
+		{name: "malformed", subShares: [][]byte{received[0][0], received[0][1][:5]}, want: ErrMalformedShare},
+		{name: "nil sub-shares", want: ErrNoShares},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CombineReshares(tt.subShares)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
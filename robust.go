@@ -0,0 +1,255 @@
+package shamir
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/wbrc/gf65536"
+)
+
+// CombineRobust combines shares like Combine, but tolerates corrupted shares.
+// Each word of the secret is decoded as a Reed-Solomon codeword, so with n
+// shares up to (n-threshold)/2 of them may hold wrong y values. The shares
+// must still decode and belong to the same dealing. If the shares record the
+// threshold, a threshold of 0 means to use it. On success, CombineRobust
+// returns the secret and the indices of the shares that had to be corrected.
+func (d *Dealer) CombineRobust(threshold int, shares [][]byte) ([]byte, []int, error) {
+	d.init()
+
+	parsed, err := d.parseShares(shares)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return d.CombineSharesRobust(threshold, parsed)
+}
+
+// CombineSharesRobust is like CombineRobust but takes the shares as Share
+// values.
+func (d *Dealer) CombineSharesRobust(threshold int, shares []Share) ([]byte, []int, error) {
+	d.init()
+
+	h, wordShares, err := checkShares(shares)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	threshold, err = robustThreshold(h, threshold, len(shares))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	secretWords, corrupted, err := combineRobust(h.field, threshold, wordShares)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	secret, err := decodeSecret(h, secretWords, shares[0].length)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var indices []int
+	for i := range corrupted {
+		if corrupted[i] {
+			indices = append(indices, i)
+		}
+	}
+
+	return secret, indices, nil
+}
+
+// CombineRobust combines shares using the default dealer.
+func CombineRobust(threshold int, shares [][]byte) ([]byte, []int, error) {
+	return Default.CombineRobust(threshold, shares)
+}
+
+// CheaterReport tells which shares agree with the polynomials that the
+// majority of shares agrees on.
+type CheaterReport struct {
+	Consistent   []uint16 // x coordinates of the shares that agree on every word
+	Inconsistent []uint16 // x coordinates of the shares that disagree on some word
+}
+
+// IdentifyCheaters checks every share against the polynomials that the majority
+// of shares agrees on, word by word across the whole secret. It needs more
+// shares than the threshold, and can identify up to (len(shares)-threshold)/2
+// inconsistent shares. If the shares record the threshold, a threshold of 0
+// means to use it.
+func (d *Dealer) IdentifyCheaters(threshold int, shares [][]byte) (CheaterReport, error) {
+	d.init()
+
+	parsed, err := d.parseShares(shares)
+	if err != nil {
+		return CheaterReport{}, err
+	}
+
+	h, wordShares, err := checkShares(parsed)
+	if err != nil {
+		return CheaterReport{}, err
+	}
+
+	threshold, err = robustThreshold(h, threshold, len(shares))
+	if err != nil {
+		return CheaterReport{}, err
+	}
+	if len(shares) == threshold {
+		return CheaterReport{}, fmt.Errorf("%w: need more shares than the threshold", ErrInsufficientOrInvalidShares)
+	}
+
+	_, corrupted, err := combineRobust(h.field, threshold, wordShares)
+	if err != nil {
+		return CheaterReport{}, err
+	}
+
+	var report CheaterReport
+	for i, share := range parsed {
+		if corrupted[i] {
+			report.Inconsistent = append(report.Inconsistent, share.X())
+		} else {
+			report.Consistent = append(report.Consistent, share.X())
+		}
+	}
+
+	return report, nil
+}
+
+// IdentifyCheaters checks shares using the default dealer.
+func IdentifyCheaters(threshold int, shares [][]byte) (CheaterReport, error) {
+	return Default.IdentifyCheaters(threshold, shares)
+}
+
+// return the threshold to decode with, given the header of the shares and the
+// threshold requested by the caller
+func robustThreshold(h header, threshold, n int) (int, error) {
+	if threshold == 0 {
+		threshold = h.threshold
+	}
+	if h.threshold != 0 && threshold != h.threshold {
+		return 0, fmt.Errorf("%w: shares record %d", ErrThresholdMismatch, h.threshold)
+	}
+	if threshold < 1 {
+		return 0, ErrThresholdTooSmall
+	}
+	if n < threshold {
+		return 0, fmt.Errorf("%w: have %d shares, need %d", ErrInsufficientOrInvalidShares, n, threshold)
+	}
+
+	return threshold, nil
+}
+
+// combine shares of which some may hold wrong y values, and return the secret
+// and for every share whether it had to be corrected
+func combineRobust(f gf65536.Field, threshold int, shares [][]uint16) ([]uint16, []bool, error) {
+	n := len(shares)
+	xvals := make([]uint16, n)
+	yvals := make([]uint16, n)
+	secrets := make([]uint16, len(shares[0])-1)
+	corrupted := make([]bool, n)
+
+	for r := range shares {
+		xvals[r] = shares[r][0]
+	}
+
+	// weights[0] interpolates the first threshold shares at 0, weights[i]
+	// interpolates them at the x coordinate of share threshold+i-1
+	weights := make([][]uint16, n-threshold+1)
+	for i := range weights {
+		x := uint16(0)
+		if i > 0 {
+			x = xvals[threshold+i-1]
+		}
+
+		weights[i] = make([]uint16, threshold)
+		err := lagrange(f, weights[i], xvals[:threshold], x)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for c := 1; c < len(shares[0]); c++ {
+		for r := range shares {
+			yvals[r] = shares[r][c]
+		}
+
+		if consistent(f, weights[1:], yvals[:threshold], yvals[threshold:]) {
+			secrets[c-1] = dot(f, weights[0], yvals[:threshold])
+			continue
+		}
+
+		polynomial, err := decodeColumn(f, threshold, xvals, yvals)
+		if err != nil {
+			return nil, nil, fmt.Errorf("word %d: %w", c-1, err)
+		}
+
+		secrets[c-1] = polynomial[0]
+		for r, x := range xvals {
+			if evalPoly(f, polynomial, x) != yvals[r] {
+				corrupted[r] = true
+			}
+		}
+	}
+
+	return secrets, corrupted, nil
+}
+
+// report whether the points predicted by interpolating known with weights
+// match others
+func consistent(f gf65536.Field, weights [][]uint16, known, others []uint16) bool {
+	for i := range others {
+		if dot(f, weights[i], known) != others[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// find the polynomial of degree less than threshold that passes through all
+// but at most (len(xvals)-threshold)/2 of the points using the
+// Berlekamp-Welch algorithm
+func decodeColumn(f gf65536.Field, threshold int, xvals, yvals []uint16) ([]uint16, error) {
+	n := len(xvals)
+	e := (n - threshold) / 2
+
+	// find the error locator E of degree e with leading coefficient 1 and Q of
+	// degree less than threshold+e with Q(x) = y * E(x) for all points:
+	//
+	// q0 + q1*x + ... + q(t+e-1)*x^(t+e-1) + y*(e0 + ... + e(e-1)*x^(e-1)) = y*x^e
+	m := make([][]uint16, n)
+	for i := range m {
+		m[i] = make([]uint16, threshold+2*e+1)
+		pows(f, m[i][:threshold+e], xvals[i])
+		for j := range e {
+			m[i][threshold+e+j] = f.Mul(yvals[i], m[i][j])
+		}
+		m[i][threshold+2*e] = f.Mul(yvals[i], m[i][e])
+	}
+
+	v, err := solve(f, m)
+	if err != nil {
+		return nil, errTooManyErrors
+	}
+
+	q := v[:threshold+e]
+	locator := append(v[threshold+e:], 1)
+
+	polynomial, r := divPoly(f, q, locator)
+	if slices.ContainsFunc(r, func(c uint16) bool { return c != 0 }) {
+		return nil, errTooManyErrors
+	}
+
+	var wrong int
+	for i, x := range xvals {
+		if evalPoly(f, polynomial, x) != yvals[i] {
+			wrong++
+		}
+	}
+	if wrong > e {
+		return nil, errTooManyErrors
+	}
+
+	return polynomial, nil
+}
+
+var errTooManyErrors = fmt.Errorf("%w: too many corrupted shares", ErrInsufficientOrInvalidShares)
@@ -0,0 +1,139 @@
+package shamir
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDealer_CombineRobust(t *testing.T) {
+	secret := []byte("paper backups degrade")
+
+	tests := []struct {
+		name      string
+		digest    bool
+		threshold int
+		n         int
+		corrupt   []int
+		wantErr   bool
+	}{
+		{name: "no errors", threshold: 3, n: 5},
+		{name: "one error", threshold: 3, n: 5, corrupt: []int{4}},
+		{name: "one error in the first threshold shares", threshold: 3, n: 5, corrupt: []int{0}},
+		{name: "two errors", threshold: 3, n: 7, corrupt: []int{1, 5}},
+		{name: "with digest", digest: true, threshold: 2, n: 6, corrupt: []int{0, 3}},
+		{name: "too many errors", threshold: 3, n: 5, corrupt: []int{1, 2}, wantErr: true},
+		{name: "detect only", threshold: 3, n: 4, corrupt: []int{1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Dealer{Digest: tt.digest}
+
+			shares, err := d.Split(tt.threshold, tt.n, secret)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, i := range tt.corrupt {
+				// flip bits in several y words
+				for j := len(shares[i]) - 1; j > len(shares[i])-10; j -= 3 {
+					shares[i][j] ^= byte(i + 1)
+				}
+			}
+
+			got, corrected, err := d.CombineRobust(0, shares)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrInsufficientOrInvalidShares) {
+					t.Fatalf("expected ErrInsufficientOrInvalidShares, got %v", err)
+				}
+				return
+			}
+
+			if !bytes.Equal(got, secret) {
+				t.Fatalf("expected %q, got %q", secret, got)
+			}
+			if !reflect.DeepEqual(corrected, tt.corrupt) {
+				t.Fatalf("expected corrected shares %v, got %v", tt.corrupt, corrected)
+			}
+		})
+	}
+}
+
+func TestDealer_CombineRobust_threshold(t *testing.T) {
+	shares, err := Split(3, 5, []byte("hello, world!"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := CombineRobust(2, shares); err == nil {
+		t.Fatal("expected error for threshold mismatch")
+	}
+	if _, _, err := CombineRobust(3, shares[:2]); !errors.Is(err, ErrInsufficientOrInvalidShares) {
+		t.Fatalf("expected ErrInsufficientOrInvalidShares, got %v", err)
+	}
+}
+
+func Test_decodeColumn(t *testing.T) {
+	poly := []uint16{5890, 301, 30222}
+	xvals := []uint16{10, 55, 16, 1111, 4242, 7, 99}
+	yvals := make([]uint16, len(xvals))
+	for i, x := range xvals {
+		yvals[i] = evalPoly(f, poly, x)
+	}
+
+	yvals[2] ^= 0xbeef
+	yvals[6] ^= 0x0001
+
+	got, err := decodeColumn(f, len(poly), xvals, yvals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, poly) {
+		t.Fatalf("expected %v, got %v", poly, got)
+	}
+
+	yvals[0] ^= 0x1234
+	if _, err := decodeColumn(f, len(poly), xvals, yvals); err == nil {
+		t.Fatal("expected error for too many errors")
+	}
+}
+
+func TestDealer_IdentifyCheaters(t *testing.T) {
+	shares, err := SplitShares(3, 7, []byte("hello, world!"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byteShares := make([][]byte, len(shares))
+	for i := range shares {
+		byteShares[i], err = shares[i].MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the holders of shares 2 and 6 cheat on different words
+	byteShares[2][len(byteShares[2])-1] ^= 0x42
+	byteShares[6][len(byteShares[6])-4] ^= 0x42
+
+	report, err := IdentifyCheaters(0, byteShares)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := CheaterReport{
+		Consistent:   []uint16{shares[0].X(), shares[1].X(), shares[3].X(), shares[4].X(), shares[5].X()},
+		Inconsistent: []uint16{shares[2].X(), shares[6].X()},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Fatalf("expected %v, got %v", want, report)
+	}
+
+	if _, err := IdentifyCheaters(0, byteShares[:3]); err == nil {
+		t.Fatal("expected error without surplus shares")
+	}
+}
@@ -3,12 +3,23 @@ package shamir
 import (
 	"crypto/rand"
 	"encoding/binary"
-	"errors"
+	"fmt"
 	"io"
+	"math"
+	"sync"
 
 	"github.com/wbrc/gf65536"
 )
 
+const (
+	// size of the blocks in which random coefficients are read
+	randBlockSize = 32 * 1024
+
+	// minimum number of words per goroutine when splitting or combining
+	// concurrently
+	minParallelWords = 64
+)
+
 var (
 	defaultField     = gf65536.Default
 	defaultRandSrc   = rand.Reader
@@ -23,41 +34,110 @@ type Dealer struct {
 	F         gf65536.Field    // the GF(2^16) field to use
 	Rand      io.Reader        // cryptographically secure random source
 	ByteOrder binary.ByteOrder // byte order for encoding/decoding bytes to GF(2^16) words
+
+	// Digest enables detection of wrong reconstructions. If set, a digest of
+	// the secret keyed with the ID of the dealing is split along with the
+	// secret, and combining fails with ErrInsufficientOrInvalidShares if the
+	// recovered secret does not match it. Share streams carry no digest, so
+	// NewSplitWriter rejects it.
+	Digest bool
+
+	// Concurrency is the number of goroutines used to split and combine large
+	// secrets. Values less than 2 disable concurrency. The result does not
+	// depend on it.
+	Concurrency int
 }
 
 // Split splits a secret into n shares such that any threshold number of shares
-// can be combined to recover the secret. The secret must be a multiple of 2
-// bytes. The threshold must be less than or equal to n, and both must be
+// can be combined to recover the secret. The secret may have any nonzero
+// length. The threshold must be less than or equal to n, and both must be
 // greater than 0. On success, Split returns a slice of n shares, each of which
-// is a distinct share.
+// is a distinct share in the encoding of Share.MarshalBinary.
 func (d *Dealer) Split(threshold, n int, secret []byte) ([][]byte, error) {
+	shares, err := d.SplitShares(threshold, n, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	byteShares := make([][]byte, len(shares))
+	for i := range shares {
+		byteShares[i], err = shares[i].MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return byteShares, nil
+}
+
+// SplitShares is like Split but returns the shares as Share values. Every
+// share records the threshold, field and byte order of the dealer, the length
+// of the secret and a random ID of the dealing. The secret is padded to an even
+// length with a 0x80 byte, followed by a zero byte if needed, before
+// splitting. Since the padding is split along with the secret, CombineShares
+// fails if it does not match the recorded length.
+func (d *Dealer) SplitShares(threshold, n int, secret []byte) ([]Share, error) {
 	d.init()
 
-	if len(secret)%2 != 0 {
-		return nil, errors.New("secret must be a multiple of 2 bytes")
+	return d.splitShares(threshold, n, nil, secret, false)
+}
+
+// split a secret into n shares, at the x coordinates xs if they are not nil
+func (d *Dealer) splitShares(threshold, n int, xs []uint16, secret []byte, manifest bool) ([]Share, error) {
+	err := checkParams(threshold, n)
+	if err != nil {
+		return nil, err
+	}
+	if xs != nil {
+		err = checkXes(xs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(secret) == 0 {
+		return nil, ErrEmptySecret
+	}
+	if len(secret) > math.MaxUint32 {
+		return nil, ErrSecretTooLong
+	}
+
+	h, err := d.newHeader(threshold)
+	if err != nil {
+		return nil, err
+	}
+	h.manifest = manifest
+
+	padded := pad(secret, h.digestSize())
+	if h.digest {
+		padded = append(padded, digest(h.setID, secret)...)
 	}
 
-	secretWords := make([]uint16, len(secret)/2)
-	_, err := binary.Decode(secret, d.ByteOrder, secretWords)
+	secretWords := make([]uint16, len(padded)/2)
+	_, err = binary.Decode(padded, h.byteOrder, secretWords)
 	if err != nil {
 		return nil, err
 	}
 
-	shares, err := split(d.F, d.Rand, threshold, n, secretWords)
+	var wordShares [][]uint16
+	if xs != nil {
+		wordShares, err = splitAt(h.field, d.Rand, threshold, xs, secretWords, d.Concurrency)
+	} else {
+		wordShares, err = split(h.field, d.Rand, threshold, n, secretWords, d.Concurrency)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	byteShares := make([][]byte, len(shares))
-	for i := range shares {
-		byteShares[i] = make([]byte, len(secret)+2)
-		_, err = binary.Encode(byteShares[i], d.ByteOrder, shares[i])
-		if err != nil {
-			return nil, err
+	shares := make([]Share, len(wordShares))
+	for i := range wordShares {
+		shares[i] = Share{
+			header: h,
+			words:  wordShares[i],
+			length: len(secret),
 		}
 	}
 
-	return byteShares, nil
+	return shares, nil
 }
 
 // Combine combines a slice of shares to recover the secret. len(shares) must be
@@ -66,31 +146,113 @@ func (d *Dealer) Split(threshold, n int, secret []byte) ([][]byte, error) {
 func (d *Dealer) Combine(shares [][]byte) ([]byte, error) {
 	d.init()
 
+	parsed, err := d.parseShares(shares)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.CombineShares(parsed)
+}
+
+// CombineShares is like Combine but takes the shares as Share values. All
+// shares must be from the same dealing. The secret is recovered in the field
+// recorded in the shares, and if the shares record the threshold, CombineShares
+// fails if there are fewer shares. If the dealing has a manifest, the shares
+// must carry inclusion proofs for the same manifest.
+func (d *Dealer) CombineShares(shares []Share) ([]byte, error) {
+	d.init()
+
+	h, wordShares, err := checkShares(shares)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkRoots(shares)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(shares) < h.threshold {
+		return nil, fmt.Errorf("%w: have %d shares, need %d", ErrInsufficientOrInvalidShares, len(shares), h.threshold)
+	}
+
+	secretWords, err := combine(h.field, wordShares, d.Concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeSecret(h, secretWords, shares[0].length)
+}
+
+func (d *Dealer) parseShares(shares [][]byte) ([]Share, error) {
 	if len(shares) == 0 {
-		return nil, errors.New("nil shares")
+		return nil, ErrNoShares
 	}
 
-	wordShares := make([][]uint16, len(shares))
+	parsed := make([]Share, len(shares))
 	for i := range shares {
-		wordShares[i] = make([]uint16, len(shares[0])/2)
-		_, err := binary.Decode(shares[i], d.ByteOrder, wordShares[i])
+		var err error
+		parsed[i], err = d.ParseShare(shares[i])
 		if err != nil {
-			return nil, err
+			return nil, &ShareError{Index: i, Err: err}
 		}
 	}
 
-	secretWords, err := combine(d.F, wordShares)
+	return parsed, nil
+}
+
+// check that shares are valid and from the same dealing, and return the
+// header of the dealing and the words of the shares
+func checkShares(shares []Share) (header, [][]uint16, error) {
+	if len(shares) == 0 {
+		return header{}, nil, ErrNoShares
+	}
+
+	h := shares[0].header
+	wordShares := make([][]uint16, len(shares))
+	xvals := make([]uint16, len(shares))
+	for i, share := range shares {
+		if len(share.words) < 2 {
+			return header{}, nil, &ShareError{Index: i, Err: ErrMalformedShare}
+		}
+		if share.setID != h.setID {
+			return header{}, nil, &ShareError{Index: i, Err: ErrDifferentDealing}
+		}
+		if share.header != h {
+			return header{}, nil, &ShareError{Index: i, Err: ErrParamsMismatch}
+		}
+		if share.length != shares[0].length || len(share.words) != len(shares[0].words) {
+			return header{}, nil, &ShareError{Index: i, Err: ErrLengthMismatch}
+		}
+		wordShares[i] = share.words
+		xvals[i] = share.X()
+	}
+
+	err := checkXes(xvals)
 	if err != nil {
-		return nil, err
+		return header{}, nil, err
 	}
 
+	return h, wordShares, nil
+}
+
+// encode recovered secret words to the secret of the given length, checking
+// its padding and digest
+func decodeSecret(h header, secretWords []uint16, length int) ([]byte, error) {
 	secret := make([]byte, len(secretWords)*2)
-	_, err = binary.Encode(secret, d.ByteOrder, secretWords)
+	_, err := binary.Encode(secret, h.byteOrder, secretWords)
 	if err != nil {
 		return nil, err
 	}
 
-	return secret, nil
+	if h.digest {
+		return checkDigest(h.setID, secret, length)
+	}
+	if h.legacy() {
+		return secret, nil
+	}
+
+	return unpad(secret, length)
 }
 
 // Default is a zero-value Dealer ready to use with default settings.
@@ -106,6 +268,16 @@ func Combine(shares [][]byte) ([]byte, error) {
 	return Default.Combine(shares)
 }
 
+// SplitShares splits a secret into Share values using the default dealer.
+func SplitShares(threshold, n int, secret []byte) ([]Share, error) {
+	return Default.SplitShares(threshold, n, secret)
+}
+
+// CombineShares combines Share values using the default dealer.
+func CombineShares(shares []Share) ([]byte, error) {
+	return Default.CombineShares(shares)
+}
+
 func (d *Dealer) init() {
 	if d.F == 0 {
 		d.F = defaultField
@@ -118,135 +290,271 @@ func (d *Dealer) init() {
 	}
 }
 
-func split(f gf65536.Field, random io.Reader, threshold, n int, secret []uint16) ([][]uint16, error) {
-	if threshold > n {
-		return nil, errors.New("threshold must be less than or equal to n")
+// a recovered secret with nonzero padding was recovered from insufficient or
+// corrupted shares
+var errInvalidPadding = fmt.Errorf("%w: invalid padding", ErrInsufficientOrInvalidShares)
+
+// pad a secret to an even length with a 0x80 byte, followed by a zero byte if
+// needed, leaving capacity for extra bytes. Unlike zero padding, the padding
+// records where the secret ends.
+func pad(secret []byte, extra int) []byte {
+	padded := make([]byte, paddedLen(len(secret)), paddedLen(len(secret))+extra)
+	copy(padded, secret)
+	padded[len(secret)] = 0x80
+
+	return padded
+}
+
+// length of a padded secret of the given length
+func paddedLen(length int) int {
+	return length + 2 - length%2
+}
+
+// strip the padding from the end of a recovered secret of the given length
+func unpad(secret []byte, length int) ([]byte, error) {
+	if length < 0 || len(secret) != paddedLen(length) || secret[length] != 0x80 {
+		return nil, errInvalidPadding
 	}
-	if threshold < 1 {
-		return nil, errors.New("threshold must be greater than 0")
+
+	for _, b := range secret[length+1:] {
+		if b != 0 {
+			return nil, errInvalidPadding
+		}
 	}
-	if n < 1 {
-		return nil, errors.New("n must be greater than 0")
+
+	return secret[:length], nil
+}
+
+// strip padding zero bytes from the end of a recovered secret of the given
+// length
+func trimZeros(secret []byte, length int) ([]byte, error) {
+	if length < 0 || length > len(secret) {
+		return nil, errInvalidPadding
+	}
+
+	for _, b := range secret[length:] {
+		if b != 0 {
+			return nil, errInvalidPadding
+		}
+	}
+
+	return secret[:length], nil
+}
+
+func split(f gf65536.Field, random io.Reader, threshold, n int, secret []uint16, workers int) ([][]uint16, error) {
+	err := checkParams(threshold, n)
+	if err != nil {
+		return nil, err
 	}
 	if len(secret) == 0 {
-		return nil, errors.New("nil secret")
+		return nil, ErrEmptySecret
 	}
 
 	xvals := make([]uint16, n)
-	z := make([]uint16, n)
-	shares := make([][]uint16, n)
-
-	err := distinctXes(random, xvals)
+	err = distinctXes(random, xvals)
 	if err != nil {
 		return nil, err
 	}
 
+	return splitAt(f, random, threshold, xvals, secret, workers)
+}
+
+// split a secret into shares at the given x coordinates, which must be
+// distinct and nonzero
+func splitAt(f gf65536.Field, random io.Reader, threshold int, xvals, secret []uint16, workers int) ([][]uint16, error) {
+	if len(secret) == 0 {
+		return nil, ErrEmptySecret
+	}
+
+	shares := make([][]uint16, len(xvals))
+	ys := make([][]uint16, len(xvals))
+
 	for i := range shares {
 		shares[i] = make([]uint16, len(secret)+1)
 		shares[i][0] = xvals[i]
+		ys[i] = shares[i][1:]
 	}
 
-	for i := range secret {
-		err = splitSingle(f, random, threshold, z, xvals, secret[i])
-		if err != nil {
-			return nil, err
-		}
-
-		for j := range shares {
-			shares[j][i+1] = z[j]
-		}
+	err := splitWords(f, random, threshold, xvals, secret, ys, workers)
+	if err != nil {
+		return nil, err
 	}
 
 	return shares, nil
 }
 
-func combine(f gf65536.Field, shares [][]uint16) ([]uint16, error) {
-	if len(shares) == 0 {
-		return nil, errors.New("nil shares")
+func checkParams(threshold, n int) error {
+	if threshold > n {
+		return ErrThresholdTooLarge
 	}
-
-	secretLen := len(shares[0]) - 1
-	for _, share := range shares[1:] {
-		if len(share) != secretLen+1 {
-			return nil, errors.New("inconsistent share length")
-		}
+	if threshold < 1 {
+		return ErrThresholdTooSmall
 	}
-
-	xvals := make([]uint16, len(shares))
-	yvals := make([]uint16, len(shares))
-	secrets := make([]uint16, secretLen)
-
-	for r := range shares {
-		xvals[r] = shares[r][0]
+	if n < 1 || n > math.MaxUint16 {
+		return ErrShareCount
 	}
 
-	for c := 1; c < len(shares[0]); c++ {
-		for r := range shares {
-			yvals[r] = shares[r][c]
-		}
+	return nil
+}
 
-		secret, err := combineSingle(f, xvals, yvals)
+// split every word of secret such that ys[j][i] is the share of secret[i] at
+// xvals[j]
+func splitWords(f gf65536.Field, random io.Reader, threshold int, xvals, secret []uint16, ys [][]uint16, workers int) error {
+	// read the random coefficients for as many words as fit into a block at
+	// once, instead of issuing a small read for every word. The blocks are
+	// read in order, so the result does not depend on the number of workers.
+	blockWords := splitBlockWords(threshold, workers)
+	coeffs := make([]uint16, min(blockWords, len(secret))*(threshold-1))
+	buf := make([]byte, len(coeffs)*2)
+
+	for i := 0; i < len(secret); i += blockWords {
+		block := min(blockWords, len(secret)-i)
+		err := readWords(random, buf[:block*(threshold-1)*2], coeffs[:block*(threshold-1)])
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		secrets[c-1] = secret
+		parallel(workers, block, func(lo, hi int) {
+			polynomial := make([]uint16, threshold)
+			for k := lo; k < hi; k++ {
+				polynomial[0] = secret[i+k]
+				copy(polynomial[1:], coeffs[k*(threshold-1):])
+
+				for j, x := range xvals {
+					ys[j][i+k] = evalPoly(f, polynomial, x)
+				}
+			}
+		})
 	}
 
-	return secrets, nil
+	return nil
 }
 
-func splitSingle(f gf65536.Field, random io.Reader, threshold int, z, xvals []uint16, secret uint16) error {
-	polynomial := make([]uint16, threshold)
+// return the number of words whose random coefficients are read at once. A
+// block holds randBlockSize bytes of coefficients, but at least enough words
+// for every worker, so large thresholds are still split concurrently.
+func splitBlockWords(threshold, workers int) int {
+	blockWords := max(randBlockSize/(2*max(threshold-1, 1)), 1)
+	if workers > 1 {
+		blockWords = max(blockWords, workers*minParallelWords)
+	}
 
-	polynomial[0] = secret
+	return blockWords
+}
 
-	err := binary.Read(random, binary.NativeEndian, polynomial[1:])
-	if err != nil {
-		return err
+func combine(f gf65536.Field, shares [][]uint16, workers int) ([]uint16, error) {
+	return interpolate(f, shares, 0, workers)
+}
+
+// return the y words at x of the polynomials through the shares
+func interpolate(f gf65536.Field, shares [][]uint16, x uint16, workers int) ([]uint16, error) {
+	if len(shares) == 0 {
+		return nil, ErrNoShares
 	}
 
-	for i, x := range xvals {
-		z[i] = evalPoly(f, polynomial, x)
+	secretLen := len(shares[0]) - 1
+	for i, share := range shares {
+		if len(share) != secretLen+1 || len(share) < 1 {
+			return nil, &ShareError{Index: i, Err: ErrLengthMismatch}
+		}
 	}
 
-	return nil
-}
+	xvals := make([]uint16, len(shares))
+	weights := make([]uint16, len(shares))
+	ys := make([]uint16, secretLen)
 
-func combineSingle(f gf65536.Field, xvals, yvals []uint16) (uint16, error) {
-	m := make([][]uint16, len(xvals))
-	for i := range m {
-		m[i] = make([]uint16, len(xvals)+1)
-		pows(f, m[i][:len(m[i])-1], xvals[i])
-		m[i][len(m[i])-1] = yvals[i]
+	for r := range shares {
+		xvals[r] = shares[r][0]
 	}
 
-	err := gauss(f, m)
+	// reject duplicate shares before interpolating, so they are reported as
+	// such instead of as a failed reconstruction
+	err := checkXes(xvals)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	return m[0][len(m[0])-1], nil
+	// the x coordinates are the same for every word, so the interpolation
+	// weights only need to be computed once
+	err = lagrange(f, weights, xvals, x)
+	if err != nil {
+		return nil, err
+	}
+
+	parallel(workers, secretLen, func(lo, hi int) {
+		yvals := make([]uint16, len(shares))
+		for c := lo + 1; c <= hi; c++ {
+			for r := range shares {
+				yvals[r] = shares[r][c]
+			}
+
+			ys[c-1] = dot(f, weights, yvals)
+		}
+	})
+
+	return ys, nil
 }
 
 // creates len(v) random distinct values of GF(2^16)\0
 func distinctXes(random io.Reader, v []uint16) error {
 	xes := make(map[uint16]struct{}, len(v))
+	buf := make([]byte, len(v)*2)
 	for i := 0; i < len(v); {
-		err := binary.Read(random, binary.NativeEndian, &v[i])
+		// read all missing values at once, rejected values are replaced in
+		// the next round
+		n := len(v) - i
+		err := readWords(random, buf[:n*2], v[i:])
 		if err != nil {
 			return err
 		}
 
-		if v[i] == 0 {
-			continue
-		}
-		if _, ok := xes[v[i]]; ok {
-			continue
+		for _, x := range v[i:] {
+			if x == 0 {
+				continue
+			}
+			if _, ok := xes[x]; ok {
+				continue
+			}
+			xes[x] = struct{}{}
+			v[i] = x
+			i++
 		}
-		xes[v[i]] = struct{}{}
-		i++
 	}
 
 	return nil
 }
+
+// fill v with random words using buf, which must hold len(v)*2 bytes
+func readWords(random io.Reader, buf []byte, v []uint16) error {
+	_, err := io.ReadFull(random, buf)
+	if err != nil {
+		return err
+	}
+
+	for i := range v {
+		v[i] = binary.NativeEndian.Uint16(buf[i*2:])
+	}
+
+	return nil
+}
+
+// call fn for consecutive ranges covering [0, n), using up to workers
+// goroutines
+func parallel(workers, n int, fn func(lo, hi int)) {
+	workers = min(workers, n/minParallelWords)
+	if workers < 2 {
+		fn(0, n)
+		return
+	}
+
+	var wg sync.WaitGroup
+	size := (n + workers - 1) / workers
+	for lo := 0; lo < n; lo += size {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn(lo, min(lo+size, n))
+		}()
+	}
+	wg.Wait()
+}
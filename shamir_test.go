@@ -28,10 +28,26 @@ func TestDealer(t *testing.T) {
 			args: args{
 				t:      3,
 				n:      5,
-				secret: []byte{0xde, 0xca, 0xfb},
+				secret: []byte{},
 			},
 			wantErr: true,
 		},
+		{
+			name: "odd secret",
+			args: args{
+				t:      3,
+				n:      5,
+				secret: []byte{0xde, 0xca, 0xfb},
+			},
+		},
+		{
+			name: "single byte secret",
+			args: args{
+				t:      2,
+				n:      2,
+				secret: []byte{0xde},
+			},
+		},
 		{
 			name: "invalid params",
 			args: args{
@@ -55,7 +71,7 @@ func TestDealer(t *testing.T) {
 		threshold := mrand.IntN(50) + 2
 		n := mrand.IntN(50) + threshold
 
-		secret := make([]byte, (mrand.IntN(100)+1)*2)
+		secret := make([]byte, mrand.IntN(200)+1)
 		_, err := rand.Read(secret)
 		if err != nil {
 			t.Fatal(err)
@@ -103,6 +119,12 @@ func TestDealer(t *testing.T) {
 			}
 
 			combined, err := d.Combine(shares[:thresReconstruct])
+			if tt.wantInvalid {
+				if err == nil {
+					t.Fatal("expected error for too few shares")
+				}
+				return
+			}
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -194,7 +216,7 @@ func Test_split(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			shares, err := split(f, tt.args.random, tt.args.threshold, tt.args.n, tt.args.secret)
+			shares, err := split(f, tt.args.random, tt.args.threshold, tt.args.n, tt.args.secret, 1)
 			if (err != nil) != tt.wantErr {
 				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
 			}
@@ -251,7 +273,7 @@ func Test_combine(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := combine(f, tt.shares)
+			got, err := combine(f, tt.shares, 1)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("combine() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -263,28 +285,164 @@ func Test_combine(t *testing.T) {
 	}
 }
 
-func Test_split_combine_single(t *testing.T) {
-	var secret uint16 = 42069
+func Test_splitWords_lagrange(t *testing.T) {
+	secret := []uint16{42069, 7, 0}
 	threshold := 5
 
-	shares := make([]uint16, threshold)
 	xvals := make([]uint16, threshold)
-
 	err := distinctXes(rand.Reader, xvals)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	err = splitSingle(f, rand.Reader, threshold, shares, xvals, secret)
+	ys := make([][]uint16, threshold)
+	for i := range ys {
+		ys[i] = make([]uint16, len(secret))
+	}
+	err = splitWords(f, rand.Reader, threshold, xvals, secret, ys, 1)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	combined, err := combineSingle(f, xvals, shares)
+	weights := make([]uint16, threshold)
+	err = lagrange(f, weights, xvals, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	yvals := make([]uint16, threshold)
+	for c := range secret {
+		for r := range ys {
+			yvals[r] = ys[r][c]
+		}
+		if got := dot(f, weights, yvals); got != secret[c] {
+			t.Fatalf("word %d: expected %d, got %d", c, secret[c], got)
+		}
+	}
+}
+
+func BenchmarkCombine(b *testing.B) {
+	secret := make([]byte, 4096)
+	_, err := rand.Read(secret)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, threshold := range []int{3, 30, 300} {
+		shares, err := Split(threshold, threshold, secret)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		for _, workers := range []int{1, 8} {
+			d := Dealer{Concurrency: workers}
+			b.Run(fmt.Sprintf("t=%d/workers=%d", threshold, workers), func(b *testing.B) {
+				b.SetBytes(int64(len(secret)))
+				for b.Loop() {
+					_, err := d.Combine(shares)
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkSplit(b *testing.B) {
+	secret := make([]byte, 4096)
+	_, err := rand.Read(secret)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, tn := range [][2]int{{2, 2}, {3, 5}, {30, 50}} {
+		for _, workers := range []int{1, 8} {
+			d := Dealer{Concurrency: workers}
+			b.Run(fmt.Sprintf("t=%d/n=%d/workers=%d", tn[0], tn[1], workers), func(b *testing.B) {
+				b.SetBytes(int64(len(secret)))
+				b.ReportAllocs()
+				for b.Loop() {
+					_, err := d.Split(tn[0], tn[1], secret)
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func Test_splitBlockWords(t *testing.T) {
+	for _, threshold := range []int{2, 3, 30, 300, 3000, 65535} {
+		for _, workers := range []int{2, 8, 32} {
+			blockWords := splitBlockWords(threshold, workers)
+			if blockWords/minParallelWords < workers {
+				t.Errorf("threshold %d, %d workers: block of %d words is too small for all workers", threshold, workers, blockWords)
+			}
+		}
+
+		if blockWords := splitBlockWords(threshold, 1); blockWords*2*(threshold-1) > max(randBlockSize, 2*(threshold-1)) {
+			t.Errorf("threshold %d: sequential block of %d words exceeds the block size", threshold, blockWords)
+		}
+	}
+}
+
+func Test_splitWords_concurrency(t *testing.T) {
+	// randBlockSize bytes hold the coefficients of fewer than minParallelWords
+	// words at this threshold
+	threshold := 300
+	xvals := []uint16{1, 2, 3}
+	secret := make([]uint16, 8*minParallelWords)
+
+	var seed [32]byte
+	split := func(workers int) [][]uint16 {
+		ys := make([][]uint16, len(xvals))
+		for i := range ys {
+			ys[i] = make([]uint16, len(secret))
+		}
+		err := splitWords(f, mrand.NewChaCha8(seed), threshold, xvals, secret, ys, workers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ys
+	}
+
+	if !reflect.DeepEqual(split(8), split(1)) {
+		t.Fatal("concurrent split differs from sequential split")
+	}
+}
+
+func TestDealer_Concurrency(t *testing.T) {
+	secret := make([]byte, 10000)
+	_, err := rand.Read(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var seed [32]byte
+	sequential := Dealer{Rand: mrand.NewChaCha8(seed)}
+	concurrent := Dealer{Rand: mrand.NewChaCha8(seed), Concurrency: 8}
+
+	want, err := sequential.Split(3, 5, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := concurrent.Split(3, 5, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatal("concurrent split differs from sequential split")
+	}
+
+	combined, err := concurrent.Combine(got[1:4])
 	if err != nil {
 		t.Fatal(err)
 	}
-	if combined != secret {
-		t.Fatalf("expected %d, got %d", secret, combined)
+	if !bytes.Equal(combined, secret) {
+		t.Fatal("concurrent combine failed")
 	}
 }
@@ -0,0 +1,394 @@
+package shamir
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/wbrc/gf65536"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = Share{}
+	_ encoding.BinaryUnmarshaler = (*Share)(nil)
+	_ encoding.TextMarshaler     = Share{}
+	_ encoding.TextUnmarshaler   = (*Share)(nil)
+)
+
+// The binary encoding of a share is
+//
+//	header | length uint32 | x uint16 | y [length/2+1]uint16 | d [8]uint16
+//
+// where length is the length of the secret in bytes, d is the share of the
+// secret's digest if the dealing has one, and the header is
+//
+//	magic [4]byte | version uint8 | flags uint8 | threshold uint16 |
+//	field uint32 | set ID [8]byte
+//
+// The field is the irreducible polynomial of the GF(2^16) field. The set ID is
+// chosen at random for every dealing. All integers are big-endian, except for
+// the y words which use the byte order of the dealer. Flag bit 0 is set for
+// little-endian y words, flag bit 1 is set if the dealing has a digest, and
+// flag bit 2 is set if the dealing has a manifest, in which case the share is
+// followed by its inclusion proof (see Manifest). The other bits are reserved.
+// The secret is padded to an even length with a 0x80 byte, followed by a zero
+// byte if needed, so the y words fix the length as well.
+//
+// Shares created before this format existed consist of the x coordinate and
+// the y words only, in the byte order of the dealer, and the secret is not
+// padded, so it has an even length. They are still decoded, and combined
+// using the field and byte order of the dealer. Such a share is mistaken for
+// the current format only if it starts with the 4 byte magic.
+var shareMagic = [4]byte{'S', 'H', 'M', 'R'}
+
+const (
+	shareVersion = 1
+	headerSize   = 20
+
+	flagLittleEndian = 1 << 0
+	flagDigest       = 1 << 1
+	flagManifest     = 1 << 2
+)
+
+// the parameters of a dealing, shared by all of its shares
+type header struct {
+	threshold int // 0 if unknown
+	field     gf65536.Field
+	byteOrder binary.ByteOrder
+	setID     [8]byte
+	digest    bool // whether a digest of the secret is split along with it
+	manifest  bool // whether the shares carry inclusion proofs
+}
+
+// Share is a single share of a secret. The zero value is not a valid share;
+// shares are created by Dealer.SplitShares or by decoding an encoded share.
+type Share struct {
+	header
+	words  []uint16        // x coordinate followed by the y words
+	length int             // length of the secret in bytes
+	proof  *inclusionProof // nil until added if the dealing has a manifest
+}
+
+// X returns the x coordinate of the share.
+func (s Share) X() uint16 {
+	if len(s.words) == 0 {
+		return 0
+	}
+
+	return s.words[0]
+}
+
+// Len returns the length of the secret in bytes.
+func (s Share) Len() int {
+	return s.length
+}
+
+// Threshold returns the number of shares needed to recover the secret, or 0 if
+// it is unknown because the share was decoded from the legacy format.
+func (s Share) Threshold() int {
+	return s.threshold
+}
+
+// Field returns the GF(2^16) field the secret was split in.
+func (s Share) Field() gf65536.Field {
+	return s.field
+}
+
+// SetID returns the random ID of the dealing the share belongs to. It is zero
+// for shares decoded from the legacy format.
+func (s Share) SetID() [8]byte {
+	return s.setID
+}
+
+// MarshalBinary encodes the share in the versioned share format. Shares decoded
+// from the legacy format are encoded in the legacy format again.
+func (s Share) MarshalBinary() ([]byte, error) {
+	if len(s.words) < 2 || s.X() == 0 || (len(s.words)-1)*2 != s.wordsSize(s.length) {
+		return nil, ErrMalformedShare
+	}
+
+	if s.legacy() {
+		return s.marshalLegacy()
+	}
+
+	b := make([]byte, 0, headerSize+4+len(s.words)*2)
+	b = s.header.append(b, shareMagic, shareVersion)
+	b = binary.BigEndian.AppendUint32(b, uint32(s.length))
+	b = binary.BigEndian.AppendUint16(b, s.X())
+	b, err := binary.Append(b, s.byteOrder, s.words[1:])
+	if err != nil {
+		return nil, err
+	}
+	if s.proof != nil {
+		b = s.proof.append(b)
+	}
+
+	return b, nil
+}
+
+// UnmarshalBinary decodes a share encoded by MarshalBinary. Shares in the
+// legacy format are decoded using the default field and byte order; use
+// Dealer.ParseShare for other settings.
+func (s *Share) UnmarshalBinary(b []byte) error {
+	share, err := Default.ParseShare(b)
+	if err != nil {
+		return err
+	}
+
+	*s = share
+	return nil
+}
+
+// MarshalText encodes the share as the hexadecimal form of MarshalBinary.
+func (s Share) MarshalText() ([]byte, error) {
+	b, err := s.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	return hex.AppendEncode(nil, b), nil
+}
+
+// UnmarshalText decodes a share encoded by MarshalText.
+func (s *Share) UnmarshalText(text []byte) error {
+	b, err := hex.AppendDecode(nil, text)
+	if err != nil {
+		return err
+	}
+
+	return s.UnmarshalBinary(b)
+}
+
+// ParseShare decodes a share encoded by Share.MarshalBinary. The field and byte
+// order of the dealer are used only for shares in the legacy format.
+func (d *Dealer) ParseShare(b []byte) (Share, error) {
+	d.init()
+
+	if !bytes.HasPrefix(b, shareMagic[:]) {
+		return parseLegacyShare(b, header{
+			field:     d.F,
+			byteOrder: canonicalOrder(d.ByteOrder),
+		})
+	}
+
+	h, err := parseHeader(b, shareMagic, shareVersion)
+	if err != nil {
+		return Share{}, err
+	}
+	encoded := b
+	b = b[headerSize:]
+
+	if len(b) < 6 {
+		return Share{}, fmt.Errorf("%w: too short", ErrMalformedShare)
+	}
+
+	length := int(binary.BigEndian.Uint32(b))
+	x := binary.BigEndian.Uint16(b[4:])
+	b = b[6:]
+
+	if length < 1 {
+		return Share{}, fmt.Errorf("%w: nil secret", ErrMalformedShare)
+	}
+	wordsSize := h.wordsSize(length)
+	if len(b) < wordsSize || (!h.manifest && len(b) != wordsSize) {
+		return Share{}, fmt.Errorf("%w: length does not match secret length", ErrMalformedShare)
+	}
+	if x == 0 {
+		return Share{}, ErrZeroX
+	}
+
+	var proof *inclusionProof
+	if h.manifest {
+		proof, err = parseProof(encoded[:headerSize+6+wordsSize], b[wordsSize:])
+		if err != nil {
+			return Share{}, err
+		}
+		b = b[:wordsSize]
+	}
+
+	words := make([]uint16, 1+len(b)/2)
+	words[0] = x
+	_, err = binary.Decode(b, h.byteOrder, words[1:])
+	if err != nil {
+		return Share{}, err
+	}
+
+	return Share{
+		header: h,
+		words:  words,
+		length: length,
+		proof:  proof,
+	}, nil
+}
+
+func (s Share) marshalLegacy() ([]byte, error) {
+	b := make([]byte, len(s.words)*2)
+	_, err := binary.Encode(b, s.byteOrder, s.words)
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func parseLegacyShare(b []byte, h header) (Share, error) {
+	if len(b) < 4 {
+		return Share{}, fmt.Errorf("%w: too short", ErrMalformedShare)
+	}
+	if len(b)%2 != 0 {
+		return Share{}, fmt.Errorf("%w: odd length", ErrMalformedShare)
+	}
+
+	words := make([]uint16, len(b)/2)
+	_, err := binary.Decode(b, h.byteOrder, words)
+	if err != nil {
+		return Share{}, err
+	}
+
+	if words[0] == 0 {
+		return Share{}, ErrZeroX
+	}
+
+	return Share{
+		header: h,
+		words:  words,
+		length: len(b) - 2,
+	}, nil
+}
+
+// create the header for a new dealing
+func (d *Dealer) newHeader(threshold int) (header, error) {
+	h := header{
+		threshold: threshold,
+		field:     d.F,
+		byteOrder: canonicalOrder(d.ByteOrder),
+		digest:    d.Digest,
+	}
+
+	_, err := io.ReadFull(d.Rand, h.setID[:])
+	if err != nil {
+		return header{}, err
+	}
+
+	return h, nil
+}
+
+func (h header) append(b []byte, magic [4]byte, version uint8) []byte {
+	var flags uint8
+	if h.byteOrder == binary.LittleEndian {
+		flags |= flagLittleEndian
+	}
+	if h.digest {
+		flags |= flagDigest
+	}
+	if h.manifest {
+		flags |= flagManifest
+	}
+
+	b = append(b, magic[:]...)
+	b = append(b, version, flags)
+	b = binary.BigEndian.AppendUint16(b, uint16(h.threshold))
+	b = binary.BigEndian.AppendUint32(b, uint32(h.field))
+	b = append(b, h.setID[:]...)
+
+	return b
+}
+
+func parseHeader(b []byte, magic [4]byte, version uint8) (header, error) {
+	if len(b) < headerSize {
+		return header{}, fmt.Errorf("%w: header too short", ErrMalformedShare)
+	}
+	if [4]byte(b) != magic {
+		return header{}, fmt.Errorf("%w: invalid magic", ErrMalformedShare)
+	}
+	if b[4] != version {
+		return header{}, fmt.Errorf("%w: unsupported version", ErrMalformedShare)
+	}
+
+	flags := b[5]
+	if flags&^(flagLittleEndian|flagDigest|flagManifest) != 0 {
+		return header{}, fmt.Errorf("%w: unsupported flags", ErrMalformedShare)
+	}
+
+	h := header{
+		threshold: int(binary.BigEndian.Uint16(b[6:])),
+		byteOrder: binary.BigEndian,
+		setID:     [8]byte(b[12:20]),
+		digest:    flags&flagDigest != 0,
+		manifest:  flags&flagManifest != 0,
+	}
+
+	if flags&flagLittleEndian != 0 {
+		h.byteOrder = binary.LittleEndian
+	}
+	if h.threshold == 0 {
+		return header{}, fmt.Errorf("%w: %w", ErrMalformedShare, ErrThresholdTooSmall)
+	}
+
+	var err error
+	h.field, err = gf65536.New(uint64(binary.BigEndian.Uint32(b[8:])))
+	if err != nil {
+		return header{}, fmt.Errorf("%w: %w", ErrMalformedShare, err)
+	}
+
+	return h, nil
+}
+
+// shares in the legacy format record no parameters
+func (h header) legacy() bool {
+	return h.threshold == 0
+}
+
+// return s with the padding of the current format added to its y words if it
+// is a share in the legacy format, whose secret is not padded. Adding a
+// constant to the y words of every share adds it to the secret, so every share
+// can be padded on its own. The header is left to the caller.
+func (s Share) withPadding() Share {
+	if !s.legacy() || len(s.words) < 2 {
+		return s
+	}
+
+	delta := make([]byte, paddedLen(s.length))
+	delta[s.length] = 0x80
+
+	words := make([]uint16, 1+len(delta)/2)
+	copy(words, s.words)
+	for c := 1; c < len(words); c++ {
+		words[c] = s.field.Add(words[c], s.byteOrder.Uint16(delta[2*(c-1):]))
+	}
+	s.words = words
+
+	return s
+}
+
+// number of bytes of the y words of a share of a secret of the given length
+func (h header) wordsSize(length int) int {
+	if h.legacy() {
+		return length
+	}
+
+	return paddedLen(length) + h.digestSize()
+}
+
+// number of bytes of the digest split along with the secret
+func (h header) digestSize() int {
+	if h.digest {
+		return digestSize
+	}
+
+	return 0
+}
+
+// return binary.BigEndian or binary.LittleEndian, whichever encodes like o
+func canonicalOrder(o binary.ByteOrder) binary.ByteOrder {
+	var b [2]byte
+	o.PutUint16(b[:], 1)
+	if b[0] == 1 {
+		return binary.LittleEndian
+	}
+
+	return binary.BigEndian
+}
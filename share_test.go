@@ -0,0 +1,276 @@
+package shamir
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"reflect"
+	"testing"
+
+	"github.com/wbrc/gf65536"
+)
+
+func TestShare_marshal(t *testing.T) {
+	for _, secret := range [][]byte{[]byte("hello, world!!"), []byte("hello, world!")} {
+		shares, err := SplitShares(2, 3, secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, share := range shares {
+			if share.Len() != len(secret) {
+				t.Fatalf("expected length %d, got %d", len(secret), share.Len())
+			}
+
+			b, err := share.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var fromBinary Share
+			if err := fromBinary.UnmarshalBinary(b); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(fromBinary, share) {
+				t.Fatalf("expected %v, got %v", share, fromBinary)
+			}
+
+			text, err := share.MarshalText()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var fromText Share
+			if err := fromText.UnmarshalText(text); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(fromText, share) {
+				t.Fatalf("expected %v, got %v", share, fromText)
+			}
+		}
+
+		got, err := CombineShares(shares[1:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, secret) {
+			t.Fatalf("expected %q, got %q", secret, got)
+		}
+	}
+}
+
+func TestShare_UnmarshalBinary(t *testing.T) {
+	tests := []struct {
+		name    string
+		b       []byte
+		x       uint16
+		len     int
+		wantErr bool
+	}{
+		{name: "empty", b: nil, wantErr: true},
+		{name: "x only", b: []byte{0, 1}, wantErr: true},
+		{name: "x and one byte", b: []byte{0, 1, 1}, wantErr: true},
+		{name: "zero x", b: []byte{0, 0, 2, 3}, wantErr: true},
+		{name: "odd", b: []byte{0, 1, 2, 3, 1}, wantErr: true},
+		{name: "even", b: []byte{0, 1, 2, 3}, x: 1, len: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s Share
+			err := s.UnmarshalBinary(tt.b)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if s.X() != tt.x || s.Len() != tt.len {
+				t.Fatalf("expected x=%d len=%d, got x=%d len=%d", tt.x, tt.len, s.X(), s.Len())
+			}
+		})
+	}
+}
+
+func TestDealer_ParseShare(t *testing.T) {
+	d := Dealer{ByteOrder: binary.LittleEndian}
+
+	share, err := d.ParseShare([]byte{0x34, 0x12, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if share.X() != 0x1234 {
+		t.Fatalf("expected x=0x1234, got %#x", share.X())
+	}
+
+	b, err := share.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, []byte{0x34, 0x12, 2, 3}) {
+		t.Fatalf("unexpected encoding %x", b)
+	}
+}
+
+func TestCombine_inconsistent(t *testing.T) {
+	shares, err := Split(2, 3, []byte("hello, world!!"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		shares [][]byte
+	}{
+		{name: "longer", shares: [][]byte{shares[0], append(shares[1], 0, 0)}},
+		{name: "shorter", shares: [][]byte{shares[0], shares[1][:len(shares[1])-2]}},
+		{name: "trailing byte", shares: [][]byte{shares[0], append(shares[1], 0)}},
+		{name: "zero value", shares: [][]byte{shares[0], {}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Combine(tt.shares); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+
+	if _, err := CombineShares([]Share{{}, {}}); err == nil {
+		t.Fatal("expected error for zero value shares")
+	}
+}
+
+func TestCombine_legacy(t *testing.T) {
+	shares := make([][]byte, 0, 4)
+	for _, s := range []string{
+		"2b5fa84e897199d026b9b469fee4090f",
+		"a9c313cbbd97c90024791b249488d987",
+		"bbf4de08656d1ed177f85ecb7b9c9fb1",
+		"e38d56eb3ae280910595df9515ca7e2d",
+	} {
+		var share Share
+		if err := share.UnmarshalText([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+		if share.Threshold() != 0 || share.SetID() != [8]byte{} {
+			t.Fatal("expected legacy share without metadata")
+		}
+
+		b, err := share.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != s {
+			t.Fatalf("expected legacy encoding %s, got %s", s, b)
+		}
+
+		shares = append(shares, []byte(s))
+	}
+
+	for i, s := range shares {
+		shares[i] = make([]byte, len(s)/2)
+		if _, err := hex.Decode(shares[i], s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	secret, err := Combine(shares)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(secret) != "hello, world!!" {
+		t.Fatalf("unexpected secret %q", secret)
+	}
+}
+
+func TestCombine_header(t *testing.T) {
+	field, err := gf65536.New(0x1100b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := Dealer{F: field, ByteOrder: binary.LittleEndian}
+	secret := []byte("hello, world!")
+
+	shares, err := d.Split(2, 3, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the default dealer picks up field and byte order from the shares
+	got, err := Combine(shares[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, secret) {
+		t.Fatalf("expected %q, got %q", secret, got)
+	}
+
+	var share Share
+	if err := share.UnmarshalBinary(shares[0]); err != nil {
+		t.Fatal(err)
+	}
+	if share.Threshold() != 2 || share.Field() != field || share.Len() != len(secret) {
+		t.Fatalf("unexpected share parameters t=%d f=%#x len=%d", share.Threshold(), share.Field(), share.Len())
+	}
+
+	other, err := d.Split(2, 3, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		shares [][]byte
+	}{
+		{name: "different dealings", shares: [][]byte{shares[0], other[1]}},
+		{name: "not enough shares", shares: shares[:1]},
+		{name: "bad version", shares: [][]byte{shares[0], withByte(shares[1], 4, 99)}},
+		{name: "bad flags", shares: [][]byte{shares[0], withByte(shares[1], 5, 0x80)}},
+		{name: "zero threshold", shares: [][]byte{shares[0], withByte(withByte(shares[1], 6, 0), 7, 0)}},
+		{name: "reducible field", shares: [][]byte{shares[0], withByte(shares[1], 11, 0)}},
+		{name: "truncated header", shares: [][]byte{shares[0], shares[1][:headerSize-1]}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Combine(tt.shares); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestCombine_length(t *testing.T) {
+	tests := []struct {
+		secret string
+		length uint32
+	}{
+		{secret: "abc", length: 4},
+		{secret: "abc", length: 2},
+		{secret: "ab\x00\x00", length: 3},
+		{secret: "ab\x00\x00", length: 5},
+		{secret: "abcd", length: 5},
+		{secret: "abcde", length: 4},
+	}
+	for _, digest := range []bool{false, true} {
+		d := Dealer{Digest: digest}
+		for _, tt := range tests {
+			shares, err := d.Split(2, 3, []byte(tt.secret))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the length is not covered by the digest, but by the padding
+			for i := range shares {
+				binary.BigEndian.PutUint32(shares[i][headerSize:], tt.length)
+			}
+
+			if got, err := d.Combine(shares); err == nil {
+				t.Fatalf("digest=%v: expected error for %q with length %d, got %q", digest, tt.secret, tt.length, got)
+			}
+		}
+	}
+}
+
+// return a copy of b with b[i] set to v
+func withByte(b []byte, i int, v byte) []byte {
+	b = bytes.Clone(b)
+	b[i] = v
+	return b
+}
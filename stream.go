@@ -0,0 +1,351 @@
+package shamir
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/wbrc/gf65536"
+)
+
+// A share stream starts with the same header as an encoded share, followed by
+// the x coordinate
+//
+//	header | x uint16
+//
+// and a sequence of chunks
+//
+//	length uint32 | y [(length+1)/2]uint16
+//
+// where length is the number of secret bytes in the chunk. The last chunk has
+// length 0. Only the chunk before it may have an odd length, in which case the
+// secret bytes are padded with a zero byte. The x coordinate and chunk lengths
+// are big-endian, the y words use the byte order recorded in the header.
+var streamMagic = [4]byte{'S', 'H', 'M', 'S'}
+
+const (
+	streamVersion    = 2
+	streamHeaderSize = headerSize + 2
+
+	// secret bytes per chunk
+	streamChunkSize = 4096
+)
+
+var errClosed = errors.New("use of closed stream")
+
+type splitWriter struct {
+	f         gf65536.Field
+	random    io.Reader
+	byteOrder binary.ByteOrder
+	threshold int
+	workers   int
+	dst       []io.Writer
+	xvals     []uint16
+	buf       []byte     // pending secret bytes
+	words     []uint16   // pending secret words
+	ys        [][]uint16 // y words of the pending chunk for every share
+	out       []byte
+	err       error
+}
+
+// NewSplitWriter returns a writer that splits everything written to it into
+// len(dst) shares such that any threshold number of shares can be combined to
+// recover it. Share i is written to dst[i] as it is produced, so the memory
+// used does not depend on the length of the secret. The caller must call Close
+// to write the end of the shares. The shares can be combined with
+// NewCombineReader. Streams carry no digest, so NewSplitWriter returns an error
+// matching ErrInvalidParams if Dealer.Digest is set.
+func (d *Dealer) NewSplitWriter(threshold int, dst []io.Writer) (io.WriteCloser, error) {
+	d.init()
+
+	err := checkParams(threshold, len(dst))
+	if err != nil {
+		return nil, err
+	}
+	if d.Digest {
+		return nil, fmt.Errorf("%w: share streams carry no digest", ErrInvalidParams)
+	}
+
+	h, err := d.newHeader(threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &splitWriter{
+		f:         h.field,
+		random:    d.Rand,
+		byteOrder: h.byteOrder,
+		threshold: threshold,
+		workers:   d.Concurrency,
+		dst:       dst,
+		xvals:     make([]uint16, len(dst)),
+		buf:       make([]byte, 0, streamChunkSize),
+		words:     make([]uint16, 0, streamChunkSize/2),
+		ys:        make([][]uint16, len(dst)),
+		out:       make([]byte, 4+streamChunkSize),
+	}
+
+	err = distinctXes(w.random, w.xvals)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range w.ys {
+		w.ys[i] = make([]uint16, streamChunkSize/2)
+	}
+
+	for i := range dst {
+		header := make([]byte, 0, streamHeaderSize)
+		header = h.append(header, streamMagic, streamVersion)
+		header = binary.BigEndian.AppendUint16(header, w.xvals[i])
+
+		_, err = dst[i].Write(header)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return w, nil
+}
+
+// NewSplitWriter returns a split writer using the default dealer.
+func NewSplitWriter(threshold int, dst []io.Writer) (io.WriteCloser, error) {
+	return Default.NewSplitWriter(threshold, dst)
+}
+
+func (w *splitWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	var n int
+	for len(p) > 0 {
+		k := copy(w.buf[len(w.buf):streamChunkSize], p)
+		w.buf = w.buf[:len(w.buf)+k]
+		p = p[k:]
+		n += k
+
+		if len(w.buf) == streamChunkSize {
+			w.err = w.flush()
+			if w.err != nil {
+				return n, w.err
+			}
+		}
+	}
+
+	return n, nil
+}
+
+func (w *splitWriter) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+
+	w.err = w.flush()
+	if w.err != nil {
+		return w.err
+	}
+
+	// the terminating chunk
+	for _, dst := range w.dst {
+		_, w.err = dst.Write([]byte{0, 0, 0, 0})
+		if w.err != nil {
+			return w.err
+		}
+	}
+
+	w.err = errClosed
+	return nil
+}
+
+// split the pending secret bytes and write them as one chunk to every share
+func (w *splitWriter) flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	length := len(w.buf)
+	if length%2 != 0 {
+		w.buf = append(w.buf, 0)
+	}
+
+	w.words = w.words[:len(w.buf)/2]
+	_, err := binary.Decode(w.buf, w.byteOrder, w.words)
+	if err != nil {
+		return err
+	}
+
+	ys := w.ys
+	for i := range ys {
+		ys[i] = ys[i][:len(w.words)]
+	}
+
+	err = splitWords(w.f, w.random, w.threshold, w.xvals, w.words, ys, w.workers)
+	if err != nil {
+		return err
+	}
+
+	out := w.out[:4+len(w.buf)]
+	binary.BigEndian.PutUint32(out, uint32(length))
+	for i, dst := range w.dst {
+		_, err = binary.Encode(out[4:], w.byteOrder, ys[i])
+		if err != nil {
+			return err
+		}
+
+		_, err = dst.Write(out)
+		if err != nil {
+			return err
+		}
+	}
+
+	w.buf = w.buf[:0]
+	return nil
+}
+
+type combineReader struct {
+	src       []io.Reader
+	combiner  *Combiner
+	ys        [][]byte // y words of the current chunk for every share
+	buf       []byte   // recovered secret bytes of the current chunk
+	pending   []byte   // part of buf not read yet
+	oddLength bool     // whether the previous chunk had an odd length
+	err       error
+}
+
+// NewCombineReader returns a reader that recovers a secret from share streams
+// written by a split writer. It reads the share headers and checks that the x
+// coordinates are distinct and that there are at least threshold shares. The
+// secret is then recovered chunk by chunk as the caller reads, so the memory
+// used does not depend on the length of the secret. Read returns an error if
+// the chunk lengths of the shares do not match.
+func (d *Dealer) NewCombineReader(src []io.Reader) (io.Reader, error) {
+	d.init()
+
+	if len(src) == 0 {
+		return nil, ErrNoShares
+	}
+
+	var h header
+	xvals := make([]uint16, len(src))
+	for i := range src {
+		var b [streamHeaderSize]byte
+		_, err := io.ReadFull(src[i], b[:])
+		if err != nil {
+			return nil, noEOF(err)
+		}
+
+		sh, err := parseHeader(b[:], streamMagic, streamVersion)
+		if err != nil {
+			return nil, &ShareError{Index: i, Err: err}
+		}
+		if sh.digest || sh.manifest {
+			return nil, &ShareError{Index: i, Err: fmt.Errorf("%w: unsupported flags", ErrMalformedShare)}
+		}
+
+		if i > 0 && sh.setID != h.setID {
+			return nil, &ShareError{Index: i, Err: ErrDifferentDealing}
+		}
+		if i > 0 && sh != h {
+			return nil, &ShareError{Index: i, Err: ErrParamsMismatch}
+		}
+		h = sh
+		xvals[i] = binary.BigEndian.Uint16(b[headerSize:])
+	}
+
+	if len(src) < h.threshold {
+		return nil, fmt.Errorf("%w: have %d shares, need %d", ErrInsufficientOrInvalidShares, len(src), h.threshold)
+	}
+
+	hd := &Dealer{F: h.field, ByteOrder: h.byteOrder, Concurrency: d.Concurrency}
+	c, err := hd.NewCombiner(xvals)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &combineReader{
+		src:      src,
+		combiner: c,
+		ys:       make([][]byte, len(src)),
+		buf:      make([]byte, streamChunkSize),
+	}
+
+	for i := range r.ys {
+		r.ys[i] = make([]byte, streamChunkSize)
+	}
+
+	return r, nil
+}
+
+// NewCombineReader returns a combine reader using the default dealer.
+func NewCombineReader(src []io.Reader) (io.Reader, error) {
+	return Default.NewCombineReader(src)
+}
+
+func (r *combineReader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+
+		r.err = r.next()
+	}
+
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+
+	return n, nil
+}
+
+// recover the next chunk of the secret
+func (r *combineReader) next() error {
+	var length uint32
+	for i, src := range r.src {
+		var b [4]byte
+		_, err := io.ReadFull(src, b[:])
+		if err != nil {
+			return noEOF(err)
+		}
+
+		l := binary.BigEndian.Uint32(b[:])
+		if i > 0 && l != length {
+			return &ShareError{Index: i, Err: fmt.Errorf("%w: inconsistent chunk length", ErrLengthMismatch)}
+		}
+		length = l
+	}
+
+	if length == 0 {
+		return io.EOF
+	}
+	if length > streamChunkSize || r.oddLength {
+		return fmt.Errorf("%w: invalid chunk length", ErrMalformedShare)
+	}
+	r.oddLength = length%2 != 0
+
+	paddedLength := length + length%2
+	for i, src := range r.src {
+		_, err := io.ReadFull(src, r.ys[i][:paddedLength])
+		if err != nil {
+			return noEOF(err)
+		}
+		r.ys[i] = r.ys[i][:paddedLength]
+	}
+
+	err := r.combiner.CombineInto(r.buf[:paddedLength], r.ys)
+	if err != nil {
+		return err
+	}
+
+	r.pending, err = trimZeros(r.buf[:paddedLength], int(length))
+	return err
+}
+
+// a share stream must not end before its terminating chunk
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
@@ -0,0 +1,222 @@
+package shamir
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestSplitWriter(t *testing.T) {
+	for _, secretLen := range []int{0, 1, 2, streamChunkSize - 1, streamChunkSize, 3*streamChunkSize + 7} {
+		secret := make([]byte, secretLen)
+		_, err := rand.Read(secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		bufs := make([]bytes.Buffer, 5)
+		dst := make([]io.Writer, len(bufs))
+		for i := range bufs {
+			dst[i] = &bufs[i]
+		}
+
+		w, err := NewSplitWriter(3, dst)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// write in odd pieces to cross chunk boundaries
+		for p := secret; len(p) > 0; {
+			k := min(len(p), 1000)
+			if _, err := w.Write(p[:k]); err != nil {
+				t.Fatal(err)
+			}
+			p = p[k:]
+		}
+
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte{1}); err == nil {
+			t.Fatal("expected error writing to closed writer")
+		}
+
+		shares := make([][]uint16, 3)
+		var length int
+		for i := range shares {
+			shares[i], length = readStream(t, bufs[i].Bytes())
+		}
+
+		if length != secretLen {
+			t.Fatalf("expected length %d, got %d", secretLen, length)
+		}
+		if secretLen == 0 {
+			continue
+		}
+
+		words, err := combine(f, shares, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := make([]byte, len(words)*2)
+		_, err = binary.Encode(got, binary.BigEndian, words)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got[:secretLen], secret) {
+			t.Fatalf("secret of length %d not recovered", secretLen)
+		}
+	}
+}
+
+func TestNewSplitWriter(t *testing.T) {
+	if _, err := NewSplitWriter(3, make([]io.Writer, 2)); err == nil {
+		t.Fatal("expected error for threshold > n")
+	}
+	if _, err := NewSplitWriter(0, make([]io.Writer, 2)); err == nil {
+		t.Fatal("expected error for threshold 0")
+	}
+}
+
+// parse a share stream into the x coordinate followed by the y words, and the
+// secret length
+func readStream(t *testing.T, b []byte) ([]uint16, int) {
+	t.Helper()
+
+	if !bytes.HasPrefix(b, streamMagic[:]) || b[4] != streamVersion {
+		t.Fatal("invalid stream header")
+	}
+
+	share := []uint16{binary.BigEndian.Uint16(b[headerSize:])}
+	b = b[streamHeaderSize:]
+
+	var secretLen int
+	for {
+		length := int(binary.BigEndian.Uint32(b))
+		b = b[4:]
+		if length == 0 {
+			break
+		}
+
+		secretLen += length
+		for range (length + 1) / 2 {
+			share = append(share, binary.BigEndian.Uint16(b))
+			b = b[2:]
+		}
+	}
+
+	if len(b) != 0 {
+		t.Fatal("trailing data after end of stream")
+	}
+
+	return share, secretLen
+}
+
+func TestCombineReader(t *testing.T) {
+	secret := make([]byte, 2*streamChunkSize+3)
+	_, err := rand.Read(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shares := splitStream(t, 3, 5, secret)
+
+	r, err := NewCombineReader([]io.Reader{
+		bytes.NewReader(shares[4]),
+		bytes.NewReader(shares[0]),
+		bytes.NewReader(shares[2]),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, secret) {
+		t.Fatal("secret not recovered")
+	}
+}
+
+func TestNewSplitWriter_digest(t *testing.T) {
+	d := &Dealer{Digest: true}
+
+	// streams carry no digest, so it is an error to ask for one
+	_, err := d.NewSplitWriter(2, []io.Writer{io.Discard, io.Discard, io.Discard})
+	if !errors.Is(err, ErrInvalidParams) {
+		t.Fatalf("expected %v, got %v", ErrInvalidParams, err)
+	}
+}
+
+func TestCombineReader_invalid(t *testing.T) {
+	shares := splitStream(t, 2, 3, []byte("hello, world!"))
+	other := splitStream(t, 2, 3, []byte("hello, world"))
+
+	tests := []struct {
+		name       string
+		src        [][]byte
+		wantNewErr bool
+	}{
+		{name: "nil", src: nil, wantNewErr: true},
+		{name: "not enough", src: [][]byte{shares[0]}, wantNewErr: true},
+		{name: "duplicate", src: [][]byte{shares[0], shares[0]}, wantNewErr: true},
+		{name: "bad magic", src: [][]byte{shares[0], append([]byte("XXXX"), shares[1][4:]...)}, wantNewErr: true},
+		{name: "short header", src: [][]byte{shares[0], shares[1][:5]}, wantNewErr: true},
+		{name: "different dealings", src: [][]byte{shares[0], other[1]}, wantNewErr: true},
+		{name: "truncated", src: [][]byte{shares[0], shares[1][:len(shares[1])-4]}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := make([]io.Reader, len(tt.src))
+			for i := range tt.src {
+				src[i] = bytes.NewReader(tt.src[i])
+			}
+
+			r, err := NewCombineReader(src)
+			if (err != nil) != tt.wantNewErr {
+				t.Fatalf("expected error %v, got %v", tt.wantNewErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			_, err = io.ReadAll(r)
+			if err == nil {
+				t.Fatal("expected read error")
+			}
+		})
+	}
+}
+
+func splitStream(t *testing.T, threshold, n int, secret []byte) [][]byte {
+	t.Helper()
+
+	bufs := make([]bytes.Buffer, n)
+	dst := make([]io.Writer, n)
+	for i := range bufs {
+		dst[i] = &bufs[i]
+	}
+
+	w, err := NewSplitWriter(threshold, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(secret); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	shares := make([][]byte, n)
+	for i := range bufs {
+		shares[i] = bufs[i].Bytes()
+	}
+
+	return shares
+}
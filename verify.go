@@ -0,0 +1,195 @@
+package shamir
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/wbrc/gf65536"
+)
+
+// VerifyError is returned by Verify if some words of the shares do not lie on a
+// polynomial of degree less than the threshold.
+type VerifyError struct {
+	Words  []int // indices of the inconsistent words
+	Shares []int // indices of the shares that disagree with the majority, if it could be determined
+}
+
+func (e *VerifyError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "inconsistent shares: %d inconsistent words", len(e.Words))
+	if len(e.Words) > 0 {
+		fmt.Fprintf(&b, " starting at word %d", e.Words[0])
+	}
+	if len(e.Shares) > 0 {
+		fmt.Fprintf(&b, ", disagreeing shares %v", e.Shares)
+	}
+
+	return b.String()
+}
+
+// Verify checks that a full set of shares is consistent, i.e. that every word
+// of the shares lies on a polynomial of degree less than the threshold, so that
+// any threshold shares recover the same secret. The secret is never
+// interpolated. If the shares record the threshold, a threshold of 0 means to
+// use it. If the shares are inconsistent, Verify returns a *VerifyError.
+func (d *Dealer) Verify(threshold int, shares [][]byte) error {
+	d.init()
+
+	parsed, err := d.parseShares(shares)
+	if err != nil {
+		return err
+	}
+
+	h, wordShares, err := checkShares(parsed)
+	if err != nil {
+		return err
+	}
+
+	threshold, err = robustThreshold(h, threshold, len(shares))
+	if err != nil {
+		return err
+	}
+
+	return verify(h.field, threshold, wordShares)
+}
+
+// Verify checks shares using the default dealer.
+func Verify(threshold int, shares [][]byte) error {
+	return Default.Verify(threshold, shares)
+}
+
+func verify(f gf65536.Field, threshold int, shares [][]uint16) error {
+	n := len(shares)
+	xvals := make([]uint16, n)
+	yvals := make([]uint16, n)
+
+	for r := range shares {
+		xvals[r] = shares[r][0]
+	}
+
+	// weights[i] interpolates the first threshold shares at the x coordinate
+	// of share threshold+i
+	weights := make([][]uint16, n-threshold)
+	for i := range weights {
+		weights[i] = make([]uint16, threshold)
+		err := lagrange(f, weights[i], xvals[:threshold], xvals[threshold+i])
+		if err != nil {
+			return err
+		}
+	}
+
+	var verr VerifyError
+	disagreeing := make([]bool, n)
+	for c := 1; c < len(shares[0]); c++ {
+		for r := range shares {
+			yvals[r] = shares[r][c]
+		}
+
+		if consistent(f, weights, yvals[:threshold], yvals[threshold:]) {
+			continue
+		}
+		verr.Words = append(verr.Words, c-1)
+
+		polynomial, err := decodeColumn(f, threshold, xvals, yvals)
+		if err != nil {
+			continue
+		}
+		for r, x := range xvals {
+			if evalPoly(f, polynomial, x) != yvals[r] {
+				disagreeing[r] = true
+			}
+		}
+	}
+
+	if len(verr.Words) == 0 {
+		return nil
+	}
+
+	for r := range disagreeing {
+		if disagreeing[r] {
+			verr.Shares = append(verr.Shares, r)
+		}
+	}
+
+	return &verr
+}
+
+// InferThreshold determines the threshold of a dealing from its shares. If the
+// shares record the threshold, it is returned. Otherwise it is inferred as one
+// more than the smallest degree of polynomials through every word of the
+// shares. This only works if there are more shares than the threshold; if
+// there are not, InferThreshold returns an error.
+func (d *Dealer) InferThreshold(shares [][]byte) (int, error) {
+	d.init()
+
+	parsed, err := d.parseShares(shares)
+	if err != nil {
+		return 0, err
+	}
+
+	h, wordShares, err := checkShares(parsed)
+	if err != nil {
+		return 0, err
+	}
+	if h.threshold != 0 {
+		return h.threshold, nil
+	}
+
+	return inferThreshold(h.field, wordShares)
+}
+
+// InferThreshold infers the threshold of shares using the default dealer.
+func InferThreshold(shares [][]byte) (int, error) {
+	return Default.InferThreshold(shares)
+}
+
+func inferThreshold(f gf65536.Field, shares [][]uint16) (int, error) {
+	m := len(shares)
+	xvals := make([]uint16, m)
+	for r := range shares {
+		xvals[r] = shares[r][0]
+	}
+
+	// inv[j][i] = 1 / (x(i) - x(i-j))
+	inv := make([][]uint16, m)
+	for j := 1; j < m; j++ {
+		inv[j] = make([]uint16, m)
+		for i := j; i < m; i++ {
+			diff := f.Add(xvals[i], xvals[i-j])
+			if diff == 0 {
+				return 0, ErrDuplicateShare
+			}
+			inv[j][i] = f.Inv(diff)
+		}
+	}
+
+	// the degree of the interpolating polynomial is the index of its highest
+	// nonzero coefficient in Newton form
+	degree := 0
+	coeffs := make([]uint16, m)
+	for c := 1; c < len(shares[0]); c++ {
+		for r := range shares {
+			coeffs[r] = shares[r][c]
+		}
+
+		// divided differences
+		for j := 1; j < m; j++ {
+			for i := m - 1; i >= j; i-- {
+				coeffs[i] = f.Mul(f.Add(coeffs[i], coeffs[i-1]), inv[j][i])
+			}
+		}
+
+		for k := m - 1; k > degree; k-- {
+			if coeffs[k] != 0 {
+				degree = k
+				break
+			}
+		}
+	}
+
+	if degree+1 >= m {
+		return 0, fmt.Errorf("%w: threshold can not be determined from %d shares: it is at least %d", ErrInsufficientOrInvalidShares, m, m)
+	}
+
+	return degree + 1, nil
+}
@@ -0,0 +1,100 @@
+package shamir
+
+import (
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	shares, err := Split(3, 6, []byte("escrowed secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Verify(0, shares); err != nil {
+		t.Fatal(err)
+	}
+
+	// exactly threshold shares are always consistent
+	if err := Verify(3, shares[:3]); err != nil {
+		t.Fatal(err)
+	}
+
+	shares[4][len(shares[4])-3] ^= 0x10
+
+	err = Verify(0, shares)
+	var verr *VerifyError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected *VerifyError, got %v", err)
+	}
+
+	want := &VerifyError{Words: []int{6}, Shares: []int{4}}
+	if !reflect.DeepEqual(verr, want) {
+		t.Fatalf("expected %v, got %v", want, verr)
+	}
+}
+
+func Test_verify_degree(t *testing.T) {
+	// points on a polynomial of degree 2
+	poly := []uint16{5890, 301, 30222}
+	share := func(x uint16) []uint16 { return []uint16{x, evalPoly(f, poly, x)} }
+	shares := [][]uint16{share(1), share(2), share(3), share(4)}
+
+	if err := verify(f, 3, shares); err != nil {
+		t.Fatal(err)
+	}
+	if err := verify(f, 2, shares); err == nil {
+		t.Fatal("expected error for degree 2 polynomial with threshold 2")
+	}
+}
+
+func TestInferThreshold(t *testing.T) {
+	shares, err := SplitShares(4, 7, []byte("legacy seal key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byteShares := make([][]byte, len(shares))
+	for i := range shares {
+		byteShares[i], err = shares[i].MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	threshold, err := InferThreshold(byteShares)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if threshold != 4 {
+		t.Fatalf("expected recorded threshold 4, got %d", threshold)
+	}
+
+	// the legacy format does not record the threshold
+	wordShares, err := split(defaultField, defaultRandSrc, 4, 7, []uint16{0x7365, 0x616c}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range wordShares {
+		byteShares[i], err = binary.Append(nil, binary.BigEndian, wordShares[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for m := 5; m <= len(shares); m++ {
+		threshold, err := InferThreshold(byteShares[:m])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if threshold != 4 {
+			t.Fatalf("expected inferred threshold 4 from %d shares, got %d", m, threshold)
+		}
+	}
+
+	if _, err := InferThreshold(byteShares[:4]); !errors.Is(err, ErrInsufficientOrInvalidShares) {
+		t.Fatalf("expected %v, got %v", ErrInsufficientOrInvalidShares, err)
+	}
+}
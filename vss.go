@@ -0,0 +1,482 @@
+package shamir
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"filippo.io/edwards25519"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = VSSShare{}
+	_ encoding.BinaryUnmarshaler = (*VSSShare)(nil)
+	_ encoding.TextMarshaler     = VSSShare{}
+	_ encoding.TextUnmarshaler   = (*VSSShare)(nil)
+	_ encoding.BinaryMarshaler   = Commitments{}
+	_ encoding.BinaryUnmarshaler = (*Commitments)(nil)
+	_ encoding.TextMarshaler     = Commitments{}
+	_ encoding.TextUnmarshaler   = (*Commitments)(nil)
+)
+
+const (
+	// number of random bytes every chunk of the secret is padded with, to
+	// hide it in its commitment
+	vssPadSize = 16
+
+	// number of secret bytes per chunk, such that every chunk and its padding
+	// fit into 31 bytes, below the order of the group
+	vssChunkSize = 31 - vssPadSize
+)
+
+// VSSDealer is a dealer for Feldman verifiable secret sharing. Instead of
+// GF(2^16), the secret is split over the scalar field of the prime-order
+// subgroup of edwards25519, the group of Ed25519, and the dealer publishes
+// commitments to the coefficients of the polynomials. Every holder can check
+// their share against the commitments, so the holders do not have to trust the
+// dealer to hand out consistent shares. The group and scalar arithmetic is
+// constant-time. A zero-value VSSDealer is ready to use with
+// crypto/rand.Reader.
+//
+// The secret is split in chunks, and every chunk is padded with vssPadSize
+// random bytes before it is shared, so the commitment g^s to a chunk s does
+// not reveal it, even if the secret is short or guessable.
+type VSSDealer struct {
+	Rand io.Reader // cryptographically secure random source
+}
+
+// Commitments are the public commitments of a dealing. Commitments[k][j] is
+// the 32-byte encoding of the point g^a for the coefficient a of x^j of the
+// polynomial that splits chunk k of the secret. Every chunk has threshold
+// commitments.
+type Commitments [][][]byte
+
+// VSSShare is a share of a secret split by a VSSDealer.
+type VSSShare struct {
+	X      uint16                 // x coordinate, 1 to n
+	Y      []*edwards25519.Scalar // y value for every chunk of the secret
+	Length int                    // length of the secret in bytes
+}
+
+// The binary encoding of a VSS share is
+//
+//	magic [4]byte | version uint8 | length uint32 | x uint16 | y [chunks][32]byte
+//
+// and the binary encoding of commitments is
+//
+//	magic [4]byte | version uint8 | threshold uint16 | c [chunks][threshold][32]byte
+//
+// where chunks is the number of chunks of the secret, y holds the canonical
+// encodings of the scalars and c the encodings of the points. The integers are
+// big-endian.
+var (
+	vssShareMagic       = [4]byte{'S', 'H', 'M', 'V'}
+	vssCommitmentsMagic = [4]byte{'S', 'H', 'M', 'C'}
+)
+
+const vssVersion = 1
+
+// Split splits a secret into n shares such that any threshold number of shares
+// can be combined to recover the secret, and returns the commitments to be
+// published along with the shares. The secret is split in chunks of 15 bytes,
+// which fit into a scalar along with the random padding.
+func (d *VSSDealer) Split(threshold, n int, secret []byte) (Commitments, []VSSShare, error) {
+	d.init()
+
+	err := checkParams(threshold, n)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(secret) == 0 {
+		return nil, nil, ErrEmptySecret
+	}
+
+	var f scalarField
+	chunks := vssChunks(len(secret))
+
+	xvals := make([]*edwards25519.Scalar, n)
+	shares := make([]VSSShare, n)
+	for i := range shares {
+		xvals[i] = scalarFromX(uint16(i + 1))
+		shares[i] = VSSShare{
+			X:      uint16(i + 1),
+			Y:      make([]*edwards25519.Scalar, chunks),
+			Length: len(secret),
+		}
+	}
+
+	commitments := make(Commitments, chunks)
+	polynomial := make([]*edwards25519.Scalar, threshold)
+	var buf [64]byte
+	for k := range commitments {
+		// the chunk in the low bytes, random padding in the high bytes, and
+		// the top byte zero, so the scalar is canonical
+		chunk := secret[k*vssChunkSize : min((k+1)*vssChunkSize, len(secret))]
+		clear(buf[:])
+		copy(buf[:], chunk)
+		_, err = io.ReadFull(d.Rand, buf[len(chunk):vssChunkSize+vssPadSize])
+		if err != nil {
+			return nil, nil, err
+		}
+		polynomial[0], err = edwards25519.NewScalar().SetCanonicalBytes(buf[:32])
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for j := 1; j < threshold; j++ {
+			_, err = io.ReadFull(d.Rand, buf[:])
+			if err != nil {
+				return nil, nil, err
+			}
+			polynomial[j], err = edwards25519.NewScalar().SetUniformBytes(buf[:])
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		commitments[k] = make([][]byte, threshold)
+		for j, a := range polynomial {
+			commitments[k][j] = edwards25519.NewIdentityPoint().ScalarBaseMult(a).Bytes()
+		}
+
+		for i, x := range xvals {
+			shares[i].Y[k] = evalPolyIn(f, polynomial, x)
+		}
+	}
+	clear(buf[:])
+
+	return commitments, shares, nil
+}
+
+// VerifyShare checks that a share is consistent with the published
+// commitments, i.e. that its y values lie on the committed polynomials. If the
+// share does not match the commitments, VerifyShare returns an error matching
+// ErrCommitmentMismatch.
+func (d *VSSDealer) VerifyShare(commitments Commitments, share VSSShare) error {
+	d.init()
+
+	points, err := decodeCommitments(commitments)
+	if err != nil {
+		return err
+	}
+	if share.X == 0 {
+		return ErrZeroX
+	}
+	if len(share.Y) != len(commitments) || share.Length < 1 || vssChunks(share.Length) != len(commitments) {
+		return ErrLengthMismatch
+	}
+
+	var f scalarField
+	x := scalarFromX(share.X)
+	pows := make([]*edwards25519.Scalar, len(points[0]))
+	pows[0] = f.One()
+	for j := 1; j < len(pows); j++ {
+		pows[j] = f.Mul(pows[j-1], x)
+	}
+
+	for k, chunk := range points {
+		y := share.Y[k]
+		if y == nil {
+			return fmt.Errorf("%w: missing y value", ErrMalformedShare)
+		}
+
+		// g^y = C[0] * C[1]^x * C[2]^(x^2) * ..., where only y is secret
+		gy := edwards25519.NewIdentityPoint().ScalarBaseMult(y)
+		c := edwards25519.NewIdentityPoint().VarTimeMultiScalarMult(pows, chunk)
+		if gy.Equal(c) != 1 {
+			return fmt.Errorf("%w: chunk %d", ErrCommitmentMismatch, k)
+		}
+	}
+
+	return nil
+}
+
+// Combine verifies shares against the commitments and recovers the secret.
+// There must be at least threshold shares, which is the number of commitments
+// per chunk; surplus shares are verified but not used.
+func (d *VSSDealer) Combine(commitments Commitments, shares []VSSShare) ([]byte, error) {
+	d.init()
+
+	points, err := decodeCommitments(commitments)
+	if err != nil {
+		return nil, err
+	}
+	threshold := len(points[0])
+	if len(shares) == 0 {
+		return nil, ErrNoShares
+	}
+
+	xvals := make([]*edwards25519.Scalar, len(shares))
+	xs := make([]uint16, len(shares))
+	for i, share := range shares {
+		err := d.VerifyShare(commitments, share)
+		if err != nil {
+			return nil, &ShareError{Index: i, Err: err}
+		}
+		if share.Length != shares[0].Length {
+			return nil, &ShareError{Index: i, Err: ErrLengthMismatch}
+		}
+		xvals[i] = scalarFromX(share.X)
+		xs[i] = share.X
+	}
+
+	err = checkXes(xs)
+	if err != nil {
+		return nil, err
+	}
+	if len(shares) < threshold {
+		return nil, fmt.Errorf("%w: have %d shares, need %d", ErrInsufficientOrInvalidShares, len(shares), threshold)
+	}
+
+	var f scalarField
+	weights := make([]*edwards25519.Scalar, threshold)
+	err = lagrangeIn(f, weights, xvals[:threshold], f.Zero())
+	if err != nil {
+		return nil, err
+	}
+
+	length := shares[0].Length
+	secret := make([]byte, length)
+	yvals := make([]*edwards25519.Scalar, threshold)
+	for k := range commitments {
+		for r := range yvals {
+			yvals[r] = shares[r].Y[k]
+		}
+
+		b := dotIn(f, weights, yvals).Bytes()
+		if b[31] != 0 {
+			return nil, ErrInsufficientOrInvalidShares
+		}
+		copy(secret[k*vssChunkSize:min((k+1)*vssChunkSize, length)], b)
+		clear(b)
+	}
+
+	return secret, nil
+}
+
+// DefaultVSS is a zero-value VSSDealer ready to use with default settings.
+var DefaultVSS = new(VSSDealer)
+
+// VerifyShare verifies a share against commitments using the default VSS
+// dealer.
+func VerifyShare(commitments Commitments, share VSSShare) error {
+	return DefaultVSS.VerifyShare(commitments, share)
+}
+
+// MarshalBinary encodes the share, so it can be sent to its holder.
+func (s VSSShare) MarshalBinary() ([]byte, error) {
+	if s.X == 0 || s.Length < 1 || s.Length > 1<<32-1 || len(s.Y) != vssChunks(s.Length) {
+		return nil, ErrMalformedShare
+	}
+
+	b := make([]byte, 0, 11+32*len(s.Y))
+	b = append(b, vssShareMagic[:]...)
+	b = append(b, vssVersion)
+	b = binary.BigEndian.AppendUint32(b, uint32(s.Length))
+	b = binary.BigEndian.AppendUint16(b, s.X)
+	for _, y := range s.Y {
+		if y == nil {
+			return nil, fmt.Errorf("%w: missing y value", ErrMalformedShare)
+		}
+		b = append(b, y.Bytes()...)
+	}
+
+	return b, nil
+}
+
+// UnmarshalBinary decodes a share encoded by MarshalBinary.
+func (s *VSSShare) UnmarshalBinary(b []byte) error {
+	if len(b) < 11 || !bytes.HasPrefix(b, vssShareMagic[:]) {
+		return fmt.Errorf("%w: not a VSS share", ErrMalformedShare)
+	}
+	if b[4] != vssVersion {
+		return fmt.Errorf("%w: unsupported version", ErrMalformedShare)
+	}
+
+	length := int(binary.BigEndian.Uint32(b[5:]))
+	x := binary.BigEndian.Uint16(b[9:])
+	b = b[11:]
+	if length < 1 || len(b) != 32*vssChunks(length) {
+		return fmt.Errorf("%w: length does not match secret length", ErrMalformedShare)
+	}
+	if x == 0 {
+		return ErrZeroX
+	}
+
+	y := make([]*edwards25519.Scalar, len(b)/32)
+	for k := range y {
+		var err error
+		y[k], err = edwards25519.NewScalar().SetCanonicalBytes(b[32*k : 32*(k+1)])
+		if err != nil {
+			return fmt.Errorf("%w: invalid y value for chunk %d", ErrMalformedShare, k)
+		}
+	}
+
+	*s = VSSShare{X: x, Y: y, Length: length}
+	return nil
+}
+
+// MarshalText encodes the share as the hexadecimal form of MarshalBinary.
+func (s VSSShare) MarshalText() ([]byte, error) {
+	b, err := s.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	return hex.AppendEncode(nil, b), nil
+}
+
+// UnmarshalText decodes a share encoded by MarshalText.
+func (s *VSSShare) UnmarshalText(text []byte) error {
+	b, err := hex.AppendDecode(nil, text)
+	if err != nil {
+		return err
+	}
+
+	return s.UnmarshalBinary(b)
+}
+
+// MarshalBinary encodes the commitments, so they can be published.
+func (c Commitments) MarshalBinary() ([]byte, error) {
+	_, err := decodeCommitments(c)
+	if err != nil {
+		return nil, err
+	}
+
+	threshold := len(c[0])
+	b := make([]byte, 0, 7+32*threshold*len(c))
+	b = append(b, vssCommitmentsMagic[:]...)
+	b = append(b, vssVersion)
+	b = binary.BigEndian.AppendUint16(b, uint16(threshold))
+	for _, chunk := range c {
+		for _, p := range chunk {
+			b = append(b, p...)
+		}
+	}
+
+	return b, nil
+}
+
+// UnmarshalBinary decodes commitments encoded by MarshalBinary. It fails with
+// an error matching ErrMalformedCommitments unless every commitment is a point
+// of the prime-order subgroup.
+func (c *Commitments) UnmarshalBinary(b []byte) error {
+	if len(b) < 7 || !bytes.HasPrefix(b, vssCommitmentsMagic[:]) {
+		return fmt.Errorf("%w: not VSS commitments", ErrMalformedCommitments)
+	}
+	if b[4] != vssVersion {
+		return fmt.Errorf("%w: unsupported version", ErrMalformedCommitments)
+	}
+
+	threshold := int(binary.BigEndian.Uint16(b[5:]))
+	b = b[7:]
+	if threshold == 0 || len(b) == 0 || len(b)%(32*threshold) != 0 {
+		return fmt.Errorf("%w: invalid length", ErrMalformedCommitments)
+	}
+
+	commitments := make(Commitments, len(b)/(32*threshold))
+	for k := range commitments {
+		commitments[k] = make([][]byte, threshold)
+		for j := range commitments[k] {
+			commitments[k][j] = bytes.Clone(b[:32])
+			b = b[32:]
+		}
+	}
+
+	_, err := decodeCommitments(commitments)
+	if err != nil {
+		return err
+	}
+
+	*c = commitments
+	return nil
+}
+
+// MarshalText encodes the commitments as the hexadecimal form of
+// MarshalBinary.
+func (c Commitments) MarshalText() ([]byte, error) {
+	b, err := c.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	return hex.AppendEncode(nil, b), nil
+}
+
+// UnmarshalText decodes commitments encoded by MarshalText.
+func (c *Commitments) UnmarshalText(text []byte) error {
+	b, err := hex.AppendDecode(nil, text)
+	if err != nil {
+		return err
+	}
+
+	return c.UnmarshalBinary(b)
+}
+
+func (d *VSSDealer) init() {
+	if d.Rand == nil {
+		d.Rand = defaultRandSrc
+	}
+}
+
+// number of chunks of a secret of the given length
+func vssChunks(length int) int {
+	return (length + vssChunkSize - 1) / vssChunkSize
+}
+
+// return x as a scalar
+func scalarFromX(x uint16) *edwards25519.Scalar {
+	var b [32]byte
+	b[0], b[1] = byte(x), byte(x>>8)
+
+	s, err := edwards25519.NewScalar().SetCanonicalBytes(b[:])
+	if err != nil {
+		panic(err)
+	}
+
+	return s
+}
+
+// decode well-formed commitments to points of the prime-order subgroup
+func decodeCommitments(commitments Commitments) ([][]*edwards25519.Point, error) {
+	if len(commitments) == 0 || len(commitments[0]) == 0 {
+		return nil, fmt.Errorf("%w: nil commitments", ErrMalformedCommitments)
+	}
+
+	threshold := len(commitments[0])
+	points := make([][]*edwards25519.Point, len(commitments))
+	for k, chunk := range commitments {
+		if len(chunk) != threshold {
+			return nil, fmt.Errorf("%w: chunk %d has %d commitments, expected %d", ErrMalformedCommitments, k, len(chunk), threshold)
+		}
+
+		points[k] = make([]*edwards25519.Point, threshold)
+		for j, c := range chunk {
+			p, err := edwards25519.NewIdentityPoint().SetBytes(c)
+			if err != nil || !inPrimeOrderSubgroup(p) {
+				return nil, fmt.Errorf("%w: invalid point for chunk %d, coefficient %d", ErrMalformedCommitments, k, j)
+			}
+			points[k][j] = p
+		}
+	}
+
+	return points, nil
+}
+
+// the inverse of the cofactor 8 modulo the group order
+var invCofactor = func() *edwards25519.Scalar {
+	var f scalarField
+	return f.Inv(scalarFromX(8))
+}()
+
+// report whether p has no small-order component. For p = q + t with q in the
+// prime-order subgroup and t of order dividing 8, 8^-1 * 8 * p = q.
+func inPrimeOrderSubgroup(p *edwards25519.Point) bool {
+	q := edwards25519.NewIdentityPoint().MultByCofactor(p)
+	q.ScalarMult(invCofactor, q)
+
+	return q.Equal(p) == 1
+}
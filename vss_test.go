@@ -0,0 +1,328 @@
+package shamir
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"filippo.io/edwards25519"
+)
+
+func TestVSSDealer(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+		n         int
+		secret    []byte
+	}{
+		{name: "single chunk", threshold: 3, n: 5, secret: []byte("correct horse battery staple")},
+		{name: "several chunks", threshold: 2, n: 3, secret: bytes.Repeat([]byte("0123456789"), 10)},
+		{name: "zero chunk", threshold: 2, n: 2, secret: make([]byte, 40)},
+		{name: "threshold 1", threshold: 1, n: 3, secret: []byte{0xff}},
+		{name: "all ones", threshold: 3, n: 4, secret: bytes.Repeat([]byte{0xff}, 50)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d VSSDealer
+
+			commitments, shares, err := d.Split(tt.threshold, tt.n, tt.secret)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, share := range shares {
+				if err := d.VerifyShare(commitments, share); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := d.Combine(commitments, shares[tt.n-tt.threshold:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.secret) {
+				t.Fatalf("expected %x, got %x", tt.secret, got)
+			}
+		})
+	}
+}
+
+func TestVSSDealer_hiding(t *testing.T) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commitments, _, err := DefaultVSS.Split(2, 3, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// searching the commitment g^s of a chunk for the chunk itself fails even
+	// for the right guess, because of the random padding
+	for k, chunk := range commitments {
+		var b [32]byte
+		copy(b[:], secret[k*vssChunkSize:min((k+1)*vssChunkSize, len(secret))])
+		guess, err := edwards25519.NewScalar().SetCanonicalBytes(b[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Equal(edwards25519.NewGeneratorPoint().ScalarBaseMult(guess).Bytes(), chunk[0]) {
+			t.Fatalf("chunk %d: commitment reveals the secret", k)
+		}
+	}
+}
+
+func TestVerifyShare(t *testing.T) {
+	commitments, shares, err := DefaultVSS.Split(3, 5, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := shares[1]
+	tampered.Y = []*edwards25519.Scalar{scalarField{}.Add(tampered.Y[0], scalarFromX(1))}
+
+	other, _, err := DefaultVSS.Split(3, 5, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// (0, -1) has order 2
+	order2 := append([]byte{0xec}, bytes.Repeat([]byte{0xff}, 30)...)
+	order2 = append(order2, 0x7f)
+	t2, err := edwards25519.NewIdentityPoint().SetBytes(order2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c1, err := edwards25519.NewIdentityPoint().SetBytes(commitments[0][1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	withOrder2 := c1.Add(c1, t2).Bytes()
+
+	tests := []struct {
+		name        string
+		commitments Commitments
+		share       VSSShare
+		want        error
+	}{
+		{name: "tampered y", commitments: commitments, share: tampered, want: ErrCommitmentMismatch},
+		{name: "moved x", commitments: commitments, share: VSSShare{X: 3, Y: shares[1].Y, Length: 6}, want: ErrCommitmentMismatch},
+		{name: "other dealing", commitments: other, share: shares[1], want: ErrCommitmentMismatch},
+		{name: "zero x", commitments: commitments, share: VSSShare{Y: shares[1].Y, Length: 6}, want: ErrZeroX},
+		{name: "wrong length", commitments: commitments, share: VSSShare{X: 2, Y: shares[1].Y, Length: 40}, want: ErrLengthMismatch},
+		{name: "nil commitments", share: shares[1], want: ErrMalformedCommitments},
+		{name: "invalid point", commitments: Commitments{{commitments[0][0], {2, 1}, commitments[0][2]}}, share: shares[1], want: ErrMalformedCommitments},
+		{name: "small-order point", commitments: Commitments{{commitments[0][0], order2, commitments[0][2]}}, share: shares[1], want: ErrMalformedCommitments},
+		{name: "small-order component", commitments: Commitments{{commitments[0][0], withOrder2, commitments[0][2]}}, share: shares[1], want: ErrMalformedCommitments},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyShare(tt.commitments, tt.share)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestVSSShare_marshal(t *testing.T) {
+	secret := bytes.Repeat([]byte("offline check "), 3)
+	commitments, shares, err := DefaultVSS.Split(2, 3, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text, err := commitments.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a holder verifies the received share against the published commitments
+	var published Commitments
+	if err := published.UnmarshalText(text); err != nil {
+		t.Fatal(err)
+	}
+
+	received := make([]VSSShare, len(shares))
+	for i, share := range shares {
+		b, err := share.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := received[i].UnmarshalBinary(b); err != nil {
+			t.Fatal(err)
+		}
+
+		text, err := share.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var fromText VSSShare
+		if err := fromText.UnmarshalText(text); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(text, mustMarshalText(t, received[i])) || !bytes.Equal(text, mustMarshalText(t, fromText)) {
+			t.Fatalf("share %d does not round-trip", i)
+		}
+
+		if err := VerifyShare(published, received[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := DefaultVSS.Combine(published, received[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, secret) {
+		t.Fatalf("expected %q, got %q", secret, got)
+	}
+}
+
+func TestVSSShare_UnmarshalBinary(t *testing.T) {
+	_, shares, err := DefaultVSS.Split(2, 3, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := shares[0].MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the order of the scalar field, which is not a canonical scalar
+	order := []byte{
+		0xed, 0xd3, 0xf5, 0x5c, 0x1a, 0x63, 0x12, 0x58, 0xd6, 0x9c, 0xf7, 0xa2, 0xde, 0xf9, 0xde, 0x14,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x10,
+	}
+
+	tests := []struct {
+		name string
+		b    []byte
+		want error
+	}{
+		{name: "nil", b: nil, want: ErrMalformedShare},
+		{name: "bad magic", b: withByte(b, 0, 'X'), want: ErrMalformedShare},
+		{name: "bad version", b: withByte(b, 4, 99), want: ErrMalformedShare},
+		{name: "truncated", b: b[:len(b)-1], want: ErrMalformedShare},
+		{name: "wrong length", b: withByte(b, 8, 40), want: ErrMalformedShare},
+		{name: "zero x", b: withByte(withByte(b, 9, 0), 10, 0), want: ErrZeroX},
+		{name: "non-canonical y", b: append(bytes.Clone(b[:11]), order...), want: ErrMalformedShare},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s VSSShare
+			err := s.UnmarshalBinary(tt.b)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestCommitments_UnmarshalBinary(t *testing.T) {
+	commitments, _, err := DefaultVSS.Split(3, 5, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := commitments.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// (0, -1) has order 2
+	order2 := append([]byte{0xec}, bytes.Repeat([]byte{0xff}, 30)...)
+	order2 = append(order2, 0x7f)
+
+	tests := []struct {
+		name string
+		b    []byte
+	}{
+		{name: "nil", b: nil},
+		{name: "bad magic", b: withByte(b, 0, 'X')},
+		{name: "bad version", b: withByte(b, 4, 99)},
+		{name: "zero threshold", b: withByte(withByte(b, 5, 0), 6, 0)},
+		{name: "truncated", b: b[:len(b)-32]},
+		{name: "no commitments", b: b[:7]},
+		{name: "small-order point", b: append(bytes.Clone(b[:len(b)-32]), order2...)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c Commitments
+			err := c.UnmarshalBinary(tt.b)
+			if !errors.Is(err, ErrMalformedCommitments) {
+				t.Fatalf("expected %v, got %v", ErrMalformedCommitments, err)
+			}
+		})
+	}
+}
+
+func TestVSSDealer_Combine_invalid(t *testing.T) {
+	commitments, shares, err := DefaultVSS.Split(3, 5, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = DefaultVSS.Combine(commitments, shares[:2])
+	if !errors.Is(err, ErrInsufficientOrInvalidShares) {
+		t.Fatalf("expected %v, got %v", ErrInsufficientOrInvalidShares, err)
+	}
+
+	_, err = DefaultVSS.Combine(commitments, []VSSShare{shares[0], shares[1], shares[0]})
+	if !errors.Is(err, ErrDuplicateShare) {
+		t.Fatalf("expected %v, got %v", ErrDuplicateShare, err)
+	}
+
+	tampered := shares[2]
+	tampered.Y = []*edwards25519.Scalar{scalarFromX(42)}
+	_, err = DefaultVSS.Combine(commitments, []VSSShare{shares[0], shares[1], tampered})
+	var serr *ShareError
+	if !errors.As(err, &serr) || serr.Index != 2 || !errors.Is(err, ErrCommitmentMismatch) {
+		t.Fatalf("expected commitment mismatch for share 2, got %v", err)
+	}
+}
+
+func Test_lagrangeIn(t *testing.T) {
+	// p(x) = 5 + 3x + 2x^2 over the scalars of edwards25519
+	var f scalarField
+	polynomial := []*edwards25519.Scalar{scalarFromX(5), scalarFromX(3), scalarFromX(2)}
+
+	xvals := []*edwards25519.Scalar{scalarFromX(1), scalarFromX(2), scalarFromX(4)}
+	yvals := make([]*edwards25519.Scalar, len(xvals))
+	for i, x := range xvals {
+		yvals[i] = evalPolyIn(f, polynomial, x)
+	}
+
+	for x := range uint16(7) {
+		w := make([]*edwards25519.Scalar, len(xvals))
+		err := lagrangeIn(f, w, xvals, scalarFromX(x))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := evalPolyIn(f, polynomial, scalarFromX(x))
+		if got := dotIn(f, w, yvals); got.Equal(want) != 1 {
+			t.Fatalf("p(%d): expected %x, got %x", x, want.Bytes(), got.Bytes())
+		}
+	}
+
+	w := make([]*edwards25519.Scalar, 2)
+	err := lagrangeIn(f, w, []*edwards25519.Scalar{scalarFromX(8), scalarFromX(8)}, f.Zero())
+	if !errors.Is(err, ErrDuplicateShare) {
+		t.Fatalf("expected %v, got %v", ErrDuplicateShare, err)
+	}
+}
+
+// return the text encoding of a VSS share
+func mustMarshalText(t *testing.T, s VSSShare) []byte {
+	t.Helper()
+
+	text, err := s.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return text
+}
@@ -0,0 +1,145 @@
+package shamir
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// The binary encoding of a bundle of shares is
+//
+//	magic [4]byte | version uint8 | count uint16 | size uint32 |
+//	shares [count][size]byte
+//
+// where the shares are encoded by Share.MarshalBinary and all have the same
+// size. All integers are big-endian.
+var bundleMagic = [4]byte{'S', 'H', 'M', 'B'}
+
+const (
+	bundleVersion    = 1
+	bundleHeaderSize = 11
+)
+
+// SplitWeighted splits a secret among participants of unequal weight. The
+// participant with index i gets a bundle of weights[i] shares with distinct x
+// coordinates, encoded as one unit, and any set of participants whose weights
+// add up to at least threshold can recover the secret with CombineWeighted.
+// Weights must be greater than 0, and the sum of the weights must be less than
+// 65536.
+func (d *Dealer) SplitWeighted(threshold int, weights []int, secret []byte) ([][]byte, error) {
+	d.init()
+
+	n := 0
+	for i, w := range weights {
+		if w < 1 {
+			return nil, fmt.Errorf("%w: participant %d: weight must be greater than 0", ErrInvalidParams, i)
+		}
+		n += min(w, math.MaxUint16+1)
+	}
+
+	shares, err := d.Split(threshold, n, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	bundles := make([][]byte, len(weights))
+	for i, w := range weights {
+		bundles[i] = appendBundle(nil, shares[:w])
+		shares = shares[w:]
+	}
+
+	return bundles, nil
+}
+
+// CombineWeighted recovers a secret from the bundles of participants created by
+// SplitWeighted. The bundles must be from the same dealing, and no x
+// coordinate may appear in two bundles, e.g. because the same bundle was
+// passed twice.
+func (d *Dealer) CombineWeighted(bundles [][]byte) ([]byte, error) {
+	d.init()
+
+	if len(bundles) == 0 {
+		return nil, ErrNoShares
+	}
+
+	var shares []Share
+	owners := make(map[uint16]int)
+	for i, b := range bundles {
+		bundle, err := d.parseBundle(b)
+		if err != nil {
+			return nil, &ShareError{Index: i, Err: err}
+		}
+
+		for _, s := range bundle {
+			if j, ok := owners[s.X()]; ok {
+				return nil, &ShareError{Index: i, Err: fmt.Errorf("%w: x coordinate %d is also in bundle %d", ErrDuplicateShare, s.X(), j)}
+			}
+			owners[s.X()] = i
+		}
+
+		shares = append(shares, bundle...)
+	}
+
+	return d.CombineShares(shares)
+}
+
+// SplitWeighted splits a secret among weighted participants using the default
+// dealer.
+func SplitWeighted(threshold int, weights []int, secret []byte) ([][]byte, error) {
+	return Default.SplitWeighted(threshold, weights, secret)
+}
+
+// CombineWeighted combines bundles using the default dealer.
+func CombineWeighted(bundles [][]byte) ([]byte, error) {
+	return Default.CombineWeighted(bundles)
+}
+
+// append the bundle of encoded shares, which must all have the same size
+func appendBundle(b []byte, shares [][]byte) []byte {
+	b = append(b, bundleMagic[:]...)
+	b = append(b, bundleVersion)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(shares)))
+	b = binary.BigEndian.AppendUint32(b, uint32(len(shares[0])))
+	for _, share := range shares {
+		b = append(b, share...)
+	}
+
+	return b
+}
+
+// decode a bundle and check that its shares are from the same dealing and
+// have distinct x coordinates
+func (d *Dealer) parseBundle(b []byte) ([]Share, error) {
+	if len(b) < bundleHeaderSize {
+		return nil, fmt.Errorf("%w: bundle too short", ErrMalformedShare)
+	}
+	if [4]byte(b) != bundleMagic {
+		return nil, fmt.Errorf("%w: invalid bundle magic", ErrMalformedShare)
+	}
+	if b[4] != bundleVersion {
+		return nil, fmt.Errorf("%w: unsupported bundle version", ErrMalformedShare)
+	}
+
+	count := int(binary.BigEndian.Uint16(b[5:]))
+	size := int(binary.BigEndian.Uint32(b[7:]))
+	b = b[bundleHeaderSize:]
+	if count == 0 || size == 0 || len(b)/size != count || len(b)%size != 0 {
+		return nil, fmt.Errorf("%w: bundle length does not match share count", ErrMalformedShare)
+	}
+
+	shares := make([]Share, count)
+	for i := range shares {
+		var err error
+		shares[i], err = d.ParseShare(b[i*size : (i+1)*size])
+		if err != nil {
+			return nil, &ShareError{Index: i, Err: err}
+		}
+	}
+
+	_, _, err := checkShares(shares)
+	if err != nil {
+		return nil, err
+	}
+
+	return shares, nil
+}
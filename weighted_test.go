@@ -0,0 +1,71 @@
+package shamir
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSplitWeighted(t *testing.T) {
+	secret := []byte("one CISO counts as two engineers")
+
+	// the CISO and three engineers
+	bundles, err := SplitWeighted(3, []int{2, 1, 1, 1}, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		bundles [][]byte
+		wantErr error
+	}{
+		{name: "CISO and one engineer", bundles: [][]byte{bundles[0], bundles[2]}},
+		{name: "three engineers", bundles: [][]byte{bundles[1], bundles[2], bundles[3]}},
+		{name: "everyone", bundles: bundles},
+		{name: "CISO alone", bundles: bundles[:1], wantErr: ErrInsufficientOrInvalidShares},
+		{name: "two engineers", bundles: bundles[2:], wantErr: ErrInsufficientOrInvalidShares},
+		{name: "same bundle twice", bundles: [][]byte{bundles[0], bundles[0]}, wantErr: ErrDuplicateShare},
+		{name: "truncated bundle", bundles: [][]byte{bundles[0], bundles[1][:len(bundles[1])-1]}, wantErr: ErrMalformedShare},
+		{name: "share instead of bundle", bundles: [][]byte{bundles[0], bundles[1][bundleHeaderSize:]}, wantErr: ErrMalformedShare},
+		{name: "nil bundles", wantErr: ErrNoShares},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CombineWeighted(tt.bundles)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, secret) {
+				t.Fatalf("expected %q, got %q", secret, got)
+			}
+		})
+	}
+}
+
+func TestSplitWeighted_invalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+		weights   []int
+		want      error
+	}{
+		{name: "zero weight", threshold: 1, weights: []int{1, 0}, want: ErrInvalidParams},
+		{name: "threshold too large", threshold: 4, weights: []int{2, 1}, want: ErrThresholdTooLarge},
+		{name: "too many shares", threshold: 2, weights: []int{65535, 1}, want: ErrShareCount},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := SplitWeighted(tt.threshold, tt.weights, []byte("secret"))
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
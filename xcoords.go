@@ -0,0 +1,81 @@
+package shamir
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// SplitAt is like Split but the shares get the given x coordinates instead of
+// random ones, e.g. 1 to n, or coordinates derived from holder IDs with
+// XCoordinates. That way a holder keeps their x coordinate across dealings.
+// The x coordinates must be distinct and nonzero, and share i has x coordinate
+// xs[i].
+func (d *Dealer) SplitAt(threshold int, xs []uint16, secret []byte) ([][]byte, error) {
+	d.init()
+
+	shares, err := d.splitShares(threshold, len(xs), xs, secret, false)
+	if err != nil {
+		return nil, err
+	}
+
+	byteShares := make([][]byte, len(shares))
+	for i := range shares {
+		byteShares[i], err = shares[i].MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return byteShares, nil
+}
+
+// SplitAt splits a secret at the given x coordinates using the default dealer.
+func SplitAt(threshold int, xs []uint16, secret []byte) ([][]byte, error) {
+	return Default.SplitAt(threshold, xs, secret)
+}
+
+// XCoordinates derives stable x coordinates from holder IDs, for use with
+// SplitAt. The x coordinate of an ID is taken from the SHA-256 hash of the ID.
+// If it is zero or already taken by an ID earlier in the list, the ID is
+// hashed again with a counter until it is not, so appending IDs never changes
+// the x coordinates of the IDs before them. The IDs must be distinct.
+func XCoordinates(ids []string) ([]uint16, error) {
+	if len(ids) > math.MaxUint16 {
+		return nil, ErrShareCount
+	}
+
+	xs := make([]uint16, len(ids))
+	taken := make(map[uint16]int, len(ids))
+	seen := make(map[string]int, len(ids))
+	for i, id := range ids {
+		if j, ok := seen[id]; ok {
+			return nil, fmt.Errorf("%w: ids %d and %d are both %q", ErrDuplicateShare, j, i, id)
+		}
+		seen[id] = i
+
+		// there are at most as many IDs as nonzero x coordinates, so a free
+		// one is always found
+		for counter := uint32(0); ; counter++ {
+			x := idX(id, counter)
+			if _, ok := taken[x]; x != 0 && !ok {
+				xs[i] = x
+				taken[x] = i
+				break
+			}
+		}
+	}
+
+	return xs, nil
+}
+
+// derive a candidate x coordinate from an ID
+func idX(id string, counter uint32) uint16 {
+	h := sha256.New()
+	h.Write([]byte("shamir x coordinate"))
+	binary.Write(h, binary.BigEndian, counter)
+	h.Write([]byte(id))
+
+	return binary.BigEndian.Uint16(h.Sum(nil))
+}
@@ -0,0 +1,91 @@
+package shamir
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestSplitAt(t *testing.T) {
+	secret := []byte("stable holders")
+	xs := []uint16{1, 2, 3, 4, 5}
+
+	shares, err := SplitAt(3, xs, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, share := range shares {
+		s, err := Default.ParseShare(share)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.X() != xs[i] {
+			t.Fatalf("share %d: expected x coordinate %d, got %d", i, xs[i], s.X())
+		}
+	}
+
+	got, err := Combine(shares[1:4])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, secret) {
+		t.Fatalf("expected %q, got %q", secret, got)
+	}
+}
+
+func TestSplitAt_invalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+		xs        []uint16
+		secret    []byte
+		want      error
+	}{
+		{name: "duplicate x", threshold: 2, xs: []uint16{1, 2, 1}, secret: []byte("secret"), want: ErrDuplicateShare},
+		{name: "zero x", threshold: 2, xs: []uint16{1, 0}, secret: []byte("secret"), want: ErrZeroX},
+		{name: "threshold too large", threshold: 3, xs: []uint16{1, 2}, secret: []byte("secret"), want: ErrThresholdTooLarge},
+		{name: "nil xs", threshold: 1, secret: []byte("secret"), want: ErrThresholdTooLarge},
+		{name: "nil secret", threshold: 2, xs: []uint16{1, 2}, want: ErrEmptySecret},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := SplitAt(tt.threshold, tt.xs, tt.secret)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestXCoordinates(t *testing.T) {
+	// enough IDs for some hashes to collide
+	ids := make([]string, 2000)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("holder-%d", i)
+	}
+
+	xs, err := XCoordinates(ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkXes(xs); err != nil {
+		t.Fatal(err)
+	}
+
+	// appending IDs does not change the x coordinates before them
+	prefix, err := XCoordinates(ids[:1000])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range prefix {
+		if prefix[i] != xs[i] {
+			t.Fatalf("id %d: expected x coordinate %d, got %d", i, xs[i], prefix[i])
+		}
+	}
+
+	if _, err := XCoordinates([]string{"alice", "bob", "alice"}); !errors.Is(err, ErrDuplicateShare) {
+		t.Fatalf("expected %v, got %v", ErrDuplicateShare, err)
+	}
+}